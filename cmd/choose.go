@@ -6,30 +6,45 @@ import (
 
 	"github.com/ViBiOh/kmux/pkg/client"
 	"github.com/ViBiOh/kmux/pkg/resource"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-func getNamespace(kube client.Kube, namespace string) string {
-	if len(namespace) != 0 {
-		return namespace
-	}
+// completeResource completes the kinds having the capability, then the objects of the chosen kind
+func completeResource(capability resource.Capability) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return resource.Names(capability), cobra.ShellCompDirectiveNoFileComp
+		}
 
-	if !allNamespace {
-		return kube.Namespace
-	}
+		if len(args) == 1 {
+			lister, err := resource.ListerFor(args[0])
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
 
-	return ""
+			clients, err = getKubernetesClient(viper.GetStringSlice("context"))
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return listObjects(cmd.Context(), lister), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 }
 
-func listObjects(ctx context.Context, namespace string, lister resource.Lister) []string {
-	output := make(chan string, len(clients))
-	successChan := make(chan struct{}, len(clients))
+func listObjects(ctx context.Context, lister resource.Lister) []string {
+	output := make(chan string, clients.Len())
+	successChan := make(chan struct{}, clients.Len())
 
 	go func() {
 		defer close(output)
 		defer close(successChan)
 
 		clients.Execute(ctx, func(ctx context.Context, kube client.Kube) error {
-			items, err := lister(ctx, kube, getNamespace(kube, namespace))
+			items, err := lister(ctx, kube, kube.Namespace)
 			if err != nil {
 				return err
 			}
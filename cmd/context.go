@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const groupPrefix = "@"
+
+// resolveContexts expands groups, glob patterns and regexp into context names, keeping the given order and without duplicate
+func resolveContexts(available, contexts []string, contextRegexp *regexp.Regexp, groups map[string][]string) ([]string, error) {
+	slices.Sort(available)
+
+	var output []string
+
+	add := func(names ...string) {
+		for _, name := range names {
+			if !slices.Contains(output, name) {
+				output = append(output, name)
+			}
+		}
+	}
+
+	for _, context := range contexts {
+		patterns := []string{context}
+
+		if groupName, ok := strings.CutPrefix(context, groupPrefix); ok {
+			group, ok := lookupGroup(groups, groupName)
+			if !ok {
+				return nil, fmt.Errorf("unknown context group `%s`", groupName)
+			}
+
+			patterns = group
+		}
+
+		for _, pattern := range patterns {
+			if !isGlob(pattern) {
+				add(pattern)
+				continue
+			}
+
+			matches, err := globContexts(available, pattern)
+			if err != nil {
+				return nil, err
+			}
+
+			add(matches...)
+		}
+	}
+
+	if contextRegexp != nil {
+		var matches []string
+
+		for _, name := range available {
+			if contextRegexp.MatchString(name) {
+				matches = append(matches, name)
+			}
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no context matching regexp `%s`", contextRegexp)
+		}
+
+		add(matches...)
+	}
+
+	return output, nil
+}
+
+// lookupGroup finds the group case-insensitively, the keys of the config being lowercased by viper
+func lookupGroup(groups map[string][]string, name string) ([]string, bool) {
+	if group, ok := groups[name]; ok {
+		return group, true
+	}
+
+	for groupName, group := range groups {
+		if strings.EqualFold(groupName, name) {
+			return group, true
+		}
+	}
+
+	return nil, false
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+func globContexts(available []string, pattern string) ([]string, error) {
+	var output []string
+
+	for _, name := range available {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, fmt.Errorf("match context pattern `%s`: %w", pattern, err)
+		}
+
+		if matched {
+			output = append(output, name)
+		}
+	}
+
+	if len(output) == 0 {
+		return nil, fmt.Errorf("no context matching `%s`", pattern)
+	}
+
+	return output, nil
+}
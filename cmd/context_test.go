@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestResolveContexts(t *testing.T) {
+	t.Parallel()
+
+	available := []string{"prod-us-east", "prod-eu-west", "prod-eu-north", "staging-eu", "dev"}
+	groups := map[string][]string{
+		"prod-eu": {"prod-eu-*"},
+		"europe":  {"prod-eu-west", "staging-eu"},
+	}
+
+	type args struct {
+		contextRegexp *regexp.Regexp
+		contexts      []string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		"names": {
+			args{
+				contexts: []string{"dev", "staging-eu"},
+			},
+			[]string{"dev", "staging-eu"},
+			false,
+		},
+		"glob": {
+			args{
+				contexts: []string{"prod-*"},
+			},
+			[]string{"prod-eu-north", "prod-eu-west", "prod-us-east"},
+			false,
+		},
+		"glob no match": {
+			args{
+				contexts: []string{"qa-*"},
+			},
+			nil,
+			true,
+		},
+		"group": {
+			args{
+				contexts: []string{"@prod-eu"},
+			},
+			[]string{"prod-eu-north", "prod-eu-west"},
+			false,
+		},
+		"group mixed case": {
+			args{
+				contexts: []string{"@Prod-EU"},
+			},
+			[]string{"prod-eu-north", "prod-eu-west"},
+			false,
+		},
+		"unknown group": {
+			args{
+				contexts: []string{"@asia"},
+			},
+			nil,
+			true,
+		},
+		"deduplicate": {
+			args{
+				contexts: []string{"@europe", "@prod-eu"},
+			},
+			[]string{"prod-eu-west", "staging-eu", "prod-eu-north"},
+			false,
+		},
+		"regexp": {
+			args{
+				contexts:      []string{"dev"},
+				contextRegexp: regexp.MustCompile(`-eu(-|$)`),
+			},
+			[]string{"dev", "prod-eu-north", "prod-eu-west", "staging-eu"},
+			false,
+		},
+		"regexp no match": {
+			args{
+				contextRegexp: regexp.MustCompile(`^asia`),
+			},
+			nil,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := resolveContexts(slices.Clone(available), testCase.args.contexts, testCase.args.contextRegexp, groups)
+
+			if (gotErr != nil) != testCase.wantErr || !slices.Equal(got, testCase.want) {
+				t.Errorf("resolveContexts() = (%v, `%v`), want (%v, error %t)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -9,42 +9,13 @@ import (
 	"github.com/ViBiOh/kmux/pkg/env"
 	"github.com/ViBiOh/kmux/pkg/resource"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var envCmd = &cobra.Command{
-	Use:   "env TYPE NAME",
-	Short: "Get all configured environment variables of containers for a given resource",
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			return []string{
-				"cronjobs",
-				"daemonsets",
-				"deployments",
-				"jobs",
-				"pods",
-				"replicasets",
-				"statefulsets",
-			}, cobra.ShellCompDirectiveNoFileComp
-		}
-
-		if len(args) == 1 {
-			lister, err := resource.ListerFor(args[0])
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			clients, err = getKubernetesClient(viper.GetStringSlice("context"))
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			return listObjects(cmd.Context(), viper.GetString("namespace"), lister), cobra.ShellCompDirectiveNoFileComp
-		}
-
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	},
-	Args: cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
+	Use:               "env TYPE NAME",
+	Short:             "Get all configured environment variables of containers for a given resource",
+	ValidArgsFunction: completeResource(resource.PodTemplate),
+	Args:              cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		kind := args[0]
 		name := args[1]
@@ -69,9 +40,7 @@ var envCmd = &cobra.Command{
 		envGetter := env.NewEnvGetter(kind, name).
 			WithContainerRegexp(containerRegexp)
 
-		clients.Execute(ctx, envGetter.Get)
-
-		return nil
+		return multiplex(ctx, cmd, envGetter.Get)
 	},
 }
 
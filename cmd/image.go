@@ -7,44 +7,16 @@ import (
 	"syscall"
 
 	"github.com/ViBiOh/kmux/pkg/client"
+	"github.com/ViBiOh/kmux/pkg/output"
 	"github.com/ViBiOh/kmux/pkg/resource"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var imageCmd = &cobra.Command{
-	Use:   "image TYPE NAME",
-	Short: "Get all image names of containers for a given resource",
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			return []string{
-				"cronjobs",
-				"daemonsets",
-				"deployments",
-				"jobs",
-				"pods",
-				"replicasets",
-				"statefulsets",
-			}, cobra.ShellCompDirectiveNoFileComp
-		}
-
-		if len(args) == 1 {
-			lister, err := resource.ListerFor(args[0])
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			clients, err = getKubernetesClient(viper.GetStringSlice("context"))
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			return listObjects(cmd.Context(), viper.GetString("namespace"), lister), cobra.ShellCompDirectiveNoFileComp
-		}
-
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	},
-	Args: cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
+	Use:               "image TYPE NAME",
+	Short:             "Get all image names of containers for a given resource",
+	ValidArgsFunction: completeResource(resource.PodTemplate),
+	Args:              cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		kind := args[0]
 		name := args[1]
@@ -66,7 +38,7 @@ var imageCmd = &cobra.Command{
 			}
 		}
 
-		clients.Execute(ctx, func(ctx context.Context, kube client.Kube) error {
+		return multiplex(ctx, cmd, func(ctx context.Context, kube client.Kube) error {
 			podSpec, err := resource.GetPodSpec(ctx, kube, kind, name)
 			if err != nil {
 				return err
@@ -77,13 +49,11 @@ var imageCmd = &cobra.Command{
 					continue
 				}
 
-				kube.Std("%s", container.Image)
+				kube.WithMeta(output.Meta{Container: container.Name}).Record(map[string]any{"image": container.Image}, "%s", container.Image)
 			}
 
 			return nil
 		})
-
-		return nil
 	},
 }
 
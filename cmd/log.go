@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
-	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -24,53 +23,60 @@ var (
 
 	noFollow bool
 
+	previousLogs bool
+	crashLogs    bool
+	podEvents    bool
+
+	sortLogs       bool
+	sortWindow     time.Duration
+	showTimestamps bool
+
+	multiline        bool
+	multilinePattern string
+
+	dedupeWindow   time.Duration
+	sampleRate     float64
+	maxLinesPerSec uint
+
+	showStats     bool
+	statsInterval time.Duration
+	statsTop      int
+
+	alertPatterns []string
+	alertExec     string
+	alertWebhook  string
+	alertInterval time.Duration
+
+	outputDir     string
+	outputMaxSize int64
+	outputBackups int
+
 	since          time.Duration
-	labelsSelector map[string]string
+	sinceTime      string
+	untilTime      string
+	tailLines      int64
+	limitBytes     int64
+	labelsSelector string
 
 	jsonColorKeys []string
 
 	logFilters []string
-	invertGrep bool
+	logWhere   string
+
+	logTemplate string
+	logFields   []string
+	invertGrep  bool
 
 	logColorFilter *color.Color
 )
 
 var logCmd = &cobra.Command{
-	Use:     "log TYPE NAME",
-	Aliases: []string{"logs"},
-	Short:   "Get logs of a given resource",
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			return []string{
-				"cronjobs",
-				"daemonsets",
-				"deployments",
-				"jobs",
-				"namespaces",
-				"nodes",
-				"pods",
-				"services",
-			}, cobra.ShellCompDirectiveNoFileComp
-		}
-
-		if len(args) == 1 {
-			lister, err := resource.ListerFor(args[0])
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			clients, err = getKubernetesClient(viper.GetStringSlice("context"))
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			return listObjects(cmd.Context(), viper.GetString("namespace"), lister), cobra.ShellCompDirectiveNoFileComp
-		}
-
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	},
+	Use:               "log TYPE NAME",
+	Aliases:           []string{"logs"},
+	Short:             "Get logs of a given resource",
+	ValidArgsFunction: completeResource(resource.PodSelector),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if !(len(args) == 2 || len(labelsSelector) != 0 || (len(args) == 1 && slices.Contains([]string{"ns", "namespace", "namespaces"}, args[0]))) {
+		if !(len(args) == 2 || len(labelsSelector) != 0 || (len(args) == 1 && resource.IsKind(args[0], "namespaces"))) {
 			return errors.New("either labels or `TYPE NAME` args must be specified")
 		}
 
@@ -82,6 +88,10 @@ var logCmd = &cobra.Command{
 			cancel()
 		}()
 
+		if err := validateSelector(labelsSelector); err != nil {
+			return err
+		}
+
 		if len(container) != 0 {
 			var err error
 
@@ -102,6 +112,73 @@ var logCmd = &cobra.Command{
 			}
 		}
 
+		logSinceTime, logUntilTime, err := parseTimeWindow(sinceTime, untilTime)
+		if err != nil {
+			return err
+		}
+
+		alertRegexes := make([]*regexp.Regexp, len(alertPatterns))
+
+		for index, alertPattern := range alertPatterns {
+			alertRegexes[index], err = regexp.Compile(alertPattern)
+			if err != nil {
+				return fmt.Errorf("compile alert pattern `%s`: %w", alertPattern, err)
+			}
+		}
+
+		if len(alertRegexes) != 0 && len(alertExec) == 0 && len(alertWebhook) == 0 {
+			return errors.New("either --alert-exec or --alert-webhook must be specified with --alert-on")
+		}
+
+		if sampleRate <= 0 || sampleRate > 1 {
+			return fmt.Errorf("sample rate must be in ]0, 1], got %g", sampleRate)
+		}
+
+		if statsTop < 0 {
+			return fmt.Errorf("stats top must be positive, got %d", statsTop)
+		}
+
+		var logQuery *log.Query
+		if len(logWhere) != 0 {
+			logQuery, err = log.ParseQuery(logWhere)
+			if err != nil {
+				return fmt.Errorf("parse where expression: %w", err)
+			}
+		}
+
+		var multilineRegexp *regexp.Regexp
+		if multiline || len(multilinePattern) != 0 {
+			pattern := multilinePattern
+			if len(pattern) == 0 {
+				pattern = log.DefaultMultilinePattern
+			}
+
+			multilineRegexp, err = regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("compile multiline pattern `%s`: %w", pattern, err)
+			}
+		}
+
+		var projection *log.Projection
+
+		switch {
+		case len(logTemplate) != 0:
+			var err error
+
+			projection, err = log.NewTemplateProjection(logTemplate)
+			if err != nil {
+				return err
+			}
+
+		case len(logFields) != 0:
+			projection = log.NewFieldsProjection(logFields)
+		}
+
+		colorRules, err := getColorRules()
+		if err != nil {
+			return err
+		}
+
 		if grepColor := viper.GetString("grepColor"); len(grepColor) != 0 {
 			logColorFilter = log.ColorFromName(strings.ToLower(grepColor))
 		}
@@ -123,46 +200,190 @@ var logCmd = &cobra.Command{
 		logger := log.NewLogger(kind, name, labelsSelector, since).
 			WithDryRun(dryRun).
 			WithContainerRegexp(containerRegexp).
-			WithNoFollow(noFollow).
+			WithNoFollow(noFollow || previousLogs).
+			WithSinceTime(logSinceTime).
+			WithUntilTime(logUntilTime).
+			WithTailLines(tailLines).
+			WithLimitBytes(limitBytes).
+			WithPrevious(previousLogs).
+			WithCrashLogs(crashLogs).
+			WithEvents(podEvents).
 			WithLogRegexes(logRegexes).
 			WithInvertRegexp(invertGrep).
 			WithColorFilter(logColorFilter).
 			WithJsonColorKeys(jsonColorKeys).
-			WithRawOutput(rawOutput)
+			WithRawOutput(rawOutput).
+			WithTimestamps(showTimestamps).
+			WithMultiline(multilineRegexp).
+			WithQuery(logQuery).
+			WithColorRules(colorRules).
+			WithProjection(projection).
+			WithDedupe(dedupeWindow)
+
+		var alerter *log.Alerter
+		if len(alertRegexes) != 0 {
+			alerter = log.NewAlerter(alertRegexes, alertInterval).WithCommand(alertExec).WithWebhook(alertWebhook)
+			logger = logger.WithAlerter(alerter)
+		}
 
-		clients.Execute(ctx, logger.Log)
+		if sampleRate < 1 || maxLinesPerSec != 0 {
+			logger = logger.WithLimiter(log.NewLimiter(sampleRate, maxLinesPerSec))
+		}
 
-		return nil
+		var stats *log.Stats
+		if showStats {
+			stats = log.NewStats(statsInterval, statsTop)
+			logger = logger.WithStats(stats)
+		}
+
+		var archive *log.Archive
+		if len(outputDir) != 0 {
+			archive, err = log.NewArchive(outputDir, outputMaxSize*1024*1024, outputBackups)
+			if err != nil {
+				return fmt.Errorf("create output directory: %w", err)
+			}
+
+			logger = logger.WithArchive(archive)
+		}
+
+		var sorter *log.Sorter
+		if sortLogs {
+			sorter = log.NewSorter(sortWindow)
+			logger = logger.WithSorter(sorter)
+		}
+
+		logClients := clients
+		if !dryRun && !noFollow && !previousLogs {
+			logClients = clients.Unbounded()
+		}
+
+		report := logClients.Execute(ctx, logger.Log)
+
+		if sorter != nil {
+			sorter.Close()
+		}
+
+		if alerter != nil {
+			alerter.Close()
+		}
+
+		if stats != nil {
+			stats.Close()
+		}
+
+		if archive != nil {
+			if err := archive.Close(); err != nil {
+				output.Err("", "close output files: %s", err)
+			}
+		}
+
+		return reportError(cmd, report)
 	},
 }
 
+func parseTimeWindow(rawSince, rawUntil string) (since, until time.Time, err error) {
+	if len(rawSince) != 0 {
+		if since, err = time.Parse(time.RFC3339, rawSince); err != nil {
+			return since, until, fmt.Errorf("parse since time: %w", err)
+		}
+	}
+
+	if len(rawUntil) != 0 {
+		if until, err = time.Parse(time.RFC3339, rawUntil); err != nil {
+			return since, until, fmt.Errorf("parse until time: %w", err)
+		}
+	}
+
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return since, until, fmt.Errorf("since time `%s` must be before until time `%s`", rawSince, rawUntil)
+	}
+
+	return since, until, nil
+}
+
+func getColorRules() ([]log.ColorRule, error) {
+	var configs []log.ColorRuleConfig
+	if err := viper.UnmarshalKey("colors", &configs); err != nil {
+		return nil, fmt.Errorf("read colors config: %w", err)
+	}
+
+	colorRules := make([]log.ColorRule, len(configs))
+
+	for index, config := range configs {
+		var err error
+
+		colorRules[index], err = log.NewColorRule(config)
+		if err != nil {
+			return nil, fmt.Errorf("color rule #%d: %w", index+1, err)
+		}
+	}
+
+	return colorRules, nil
+}
+
 func initLog() {
 	flags := logCmd.Flags()
 
 	flags.DurationVarP(&since, "since", "s", time.Hour, "Display logs since given duration")
+	flags.StringVarP(&sinceTime, "since-time", "", "", "Display logs since given RFC3339 time, e.g. 2024-01-01T10:00:00Z, instead of --since")
+	flags.StringVarP(&untilTime, "until-time", "", "", "Display logs until given RFC3339 time, e.g. 2024-01-01T11:00:00Z")
+	flags.Int64VarP(&tailLines, "tail", "", -1, "Number of last lines of each container to display, -1 for all")
+	flags.Int64VarP(&limitBytes, "limit-bytes", "", 0, "Maximum bytes of logs of each container, 0 for no limit")
 	flags.StringVarP(&container, "container", "c", "", "Filter container's name by regexp, default to all containers")
 
 	flags.BoolVarP(&dryRun, "dry-run", "d", false, "Dry-run, print only pods")
 	flags.BoolVarP(&rawOutput, "raw-output", "r", false, "Raw output, don't print context or pod prefixes")
 
 	flags.BoolVarP(&noFollow, "no-follow", "", false, "Don't follow logs")
+	flags.BoolVarP(&previousLogs, "previous", "p", false, "Print the logs of the previous instance of containers, implies --no-follow")
+	flags.BoolVarP(&crashLogs, "crash-logs", "", false, "Print the last logs of the terminated instance when a followed container restarts")
+	flags.BoolVarP(&podEvents, "events", "", false, "Also print the Kubernetes events of the pods, e.g. OOMKilled or failed probes")
+
+	flags.StringVarP(&logTemplate, "template", "", "", "Go template for printing JSON logs, e.g. {{.level}} {{.msg}}")
+	flags.StringSliceVarP(&logFields, "fields", "", nil, "Fields of JSON logs to print, dotted for nested objects, e.g. ts,level,msg")
+	logCmd.MarkFlagsMutuallyExclusive("template", "fields")
+
+	flags.BoolVarP(&multiline, "multiline", "m", false, "Group indented lines with the previous one, e.g. stack traces, for coloring, grepping and printing them as a whole")
+	flags.StringVarP(&multilinePattern, "multiline-pattern", "", "", "Regexp of continuation lines grouped with the previous one, implies --multiline")
+
+	flags.DurationVarP(&dedupeWindow, "dedupe", "", 0, "Collapse the consecutive lines of a container repeated during given window, ignoring their numbers and ids, 0 to disable")
+	flags.Float64VarP(&sampleRate, "sample", "", 1, "Rate of lines printed per pod, e.g. 0.1 for 1 line out of 10")
+	flags.UintVarP(&maxLinesPerSec, "max-lines-per-sec", "", 0, "Maximum number of lines printed per second and pod, 0 for no limit")
+
+	flags.BoolVarP(&showStats, "stats", "", false, "Print statistics of logs per pod instead of lines: count by severity and most frequent messages")
+	flags.DurationVarP(&statsInterval, "stats-interval", "", 10*time.Second, "Delay between two prints of statistics, 0 for only a summary on exit")
+	flags.IntVarP(&statsTop, "stats-top", "", 5, "Number of most frequent messages printed per pod in statistics")
+
+	flags.StringArrayVarP(&alertPatterns, "alert-on", "", nil, "Regexp of log lines triggering the alert actions, whatever the filters")
+	flags.StringVarP(&alertExec, "alert-exec", "", "", "Command run with sh when alerting, the line being in KMUX_ALERT_LINE and its origin in KMUX_ALERT_CONTEXT, KMUX_ALERT_POD, etc.")
+	flags.StringVarP(&alertWebhook, "alert-webhook", "", "", "URL receiving a POST of the alert as JSON")
+	flags.DurationVarP(&alertInterval, "alert-interval", "", time.Minute, "Minimum delay between two alerts of the same pattern, the suppressed ones being counted")
+
+	flags.StringVarP(&outputDir, "output-dir", "", "", "Also write logs, uncolored, to one file per context, namespace, pod and container in given directory")
+	flags.Int64VarP(&outputMaxSize, "output-max-size", "", 100, "Size in MB of an output file before rotating it, 0 for no rotation")
+	flags.IntVarP(&outputBackups, "output-backups", "", 3, "Number of rotated output files kept")
+
+	flags.BoolVarP(&sortLogs, "sort", "", false, "Merge logs of all pods and contexts ordered by timestamp")
+	flags.DurationVarP(&sortWindow, "sort-window", "", 2*time.Second, "Delay for reordering logs when sorting, longer is more accurate")
+	flags.BoolVarP(&showTimestamps, "timestamps", "", false, "Display the timestamp of each log line")
 
-	flags.StringToStringVarP(&labelsSelector, "selector", "l", nil, "Labels to filter pods")
+	flags.StringVarP(&labelsSelector, "selector", "l", "", "Labels selector to filter pods, e.g. app=api,tier in (web,worker)")
 
 	flags.StringArrayVarP(&logFilters, "grep", "g", nil, "Regexp to filter log")
 	flags.BoolVarP(&invertGrep, "invert-match", "v", false, "Invert regexp filter matching")
+	flags.StringVarP(&logWhere, "where", "w", "", "Filter JSON logs on their fields, e.g. level in (error,warn) && latency_ms > 500 && path ~ \"^/api\"")
 
-	flags.String("grepColor", "", "Get logs only above given color (red > yellow > green)")
+	flags.String("grepColor", "", "Get logs only above given color (red > yellow > white > green)")
 	if err := viper.BindPFlag("grepColor", flags.Lookup("grepColor")); err != nil {
 		output.Fatal("bind `grepColor` flag: %s", err)
 	}
 
-	flags.StringSlice("levelKeys", []string{"level", "severity"}, "Keys for level in JSON")
+	flags.StringSlice("levelKeys", []string{"level", "severity", "log.level"}, "Keys for level in JSON or logfmt, dotted for nested objects")
 	if err := viper.BindPFlag("levelKeys", flags.Lookup("levelKeys")); err != nil {
 		output.Fatal("bind `levelKeys` flag: %s", err)
 	}
 
-	flags.StringSlice("statusCodeKeys", []string{"status", "statusCode", "response_code", "http_status", "OriginStatus"}, "Keys for HTTP Status code in JSON")
+	flags.StringSlice("statusCodeKeys", []string{"status", "statusCode", "response_code", "http_status", "OriginStatus", "http.response.status_code"}, "Keys for HTTP Status code in JSON or logfmt, dotted for nested objects")
 	if err := viper.BindPFlag("statusCodeKeys", flags.Lookup("statusCodeKeys")); err != nil {
 		output.Fatal("bind `statusCodeKeys` flag: %s", err)
 	}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+type namespaceMapping struct {
+	flag          map[string]string
+	config        map[string]string
+	flagNamespace string
+}
+
+// newNamespaceMapping splits the namespace applied to every context from the `context=namespace` mappings
+func newNamespaceMapping(values []string, config map[string]string) (namespaceMapping, error) {
+	mapping := namespaceMapping{
+		flag:   make(map[string]string),
+		config: config,
+	}
+
+	for _, value := range values {
+		context, namespace, ok := strings.Cut(value, "=")
+		if !ok {
+			if len(mapping.flagNamespace) != 0 && mapping.flagNamespace != value {
+				return mapping, fmt.Errorf("only one namespace without context can be set, got `%s` and `%s`", mapping.flagNamespace, value)
+			}
+
+			mapping.flagNamespace = value
+
+			continue
+		}
+
+		if len(context) == 0 || len(namespace) == 0 {
+			return mapping, fmt.Errorf("invalid namespace mapping `%s`, want `context=namespace`", value)
+		}
+
+		mapping.flag[context] = namespace
+	}
+
+	return mapping, nil
+}
+
+// configNamespaces reads the mapping of the config file from its raw value, the contexts' names being able to contain dots that viper's typed getters split as nested keys
+func configNamespaces(value any) (map[string]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	raw, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("namespaces must map contexts to namespaces, got `%v`", value)
+	}
+
+	mapping := make(map[string]string, len(raw))
+
+	for context, namespace := range raw {
+		name, ok := namespace.(string)
+		if !ok {
+			return nil, fmt.Errorf("namespace of context `%s` must be a string, got `%v`", context, namespace)
+		}
+
+		mapping[context] = name
+	}
+
+	return mapping, nil
+}
+
+// override finds the namespace of the context: from the flag mapping, the flag namespace then the config mapping
+func (nm namespaceMapping) override(context string) string {
+	if namespace := mappedNamespace(context, nm.flag); len(namespace) != 0 {
+		return namespace
+	}
+
+	if len(nm.flagNamespace) != 0 {
+		return nm.flagNamespace
+	}
+
+	return mappedNamespace(context, nm.config)
+}
+
+// mappedNamespace matches the context case-insensitively, the keys of the config mapping being lowercased by viper
+func mappedNamespace(context string, mapping map[string]string) string {
+	if namespace, ok := mapping[context]; ok {
+		return namespace
+	}
+
+	patterns := slices.Sorted(maps.Keys(mapping))
+	lowerContext := strings.ToLower(context)
+
+	for _, pattern := range patterns {
+		if !isGlob(pattern) && strings.EqualFold(pattern, context) {
+			return mapping[pattern]
+		}
+	}
+
+	for _, pattern := range patterns {
+		if !isGlob(pattern) {
+			continue
+		}
+
+		if matched, _ := path.Match(strings.ToLower(pattern), lowerContext); matched {
+			return mapping[pattern]
+		}
+	}
+
+	return ""
+}
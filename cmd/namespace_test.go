@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNamespaceMappingOverride(t *testing.T) {
+	t.Parallel()
+
+	config := map[string]string{
+		"prod-eu-*": "payments-eu",
+		"staging":   "payments-staging",
+		"gke.prod":  "payments-gke",
+	}
+
+	type args struct {
+		context string
+		values  []string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    string
+		wantErr bool
+	}{
+		"none": {
+			args{
+				context: "dev",
+			},
+			"",
+			false,
+		},
+		"config exact": {
+			args{
+				context: "staging",
+			},
+			"payments-staging",
+			false,
+		},
+		"config glob": {
+			args{
+				context: "prod-eu-west",
+			},
+			"payments-eu",
+			false,
+		},
+		"config dotted context": {
+			args{
+				context: "gke.prod",
+			},
+			"payments-gke",
+			false,
+		},
+		"config mixed case": {
+			args{
+				context: "Staging",
+			},
+			"payments-staging",
+			false,
+		},
+		"config glob mixed case": {
+			args{
+				context: "Prod-EU-West",
+			},
+			"payments-eu",
+			false,
+		},
+		"flag namespace before config": {
+			args{
+				context: "staging",
+				values:  []string{"payments"},
+			},
+			"payments",
+			false,
+		},
+		"flag mapping before flag namespace": {
+			args{
+				context: "prod-eu-west",
+				values:  []string{"payments", "prod-eu-west=payments-west"},
+			},
+			"payments-west",
+			false,
+		},
+		"many namespaces": {
+			args{
+				values: []string{"payments", "monitoring"},
+			},
+			"",
+			true,
+		},
+		"invalid mapping": {
+			args{
+				values: []string{"prod-eu-west="},
+			},
+			"",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			mapping, gotErr := newNamespaceMapping(testCase.args.values, config)
+			if (gotErr != nil) != testCase.wantErr {
+				t.Errorf("newNamespaceMapping() = `%v`, want error %t", gotErr, testCase.wantErr)
+				return
+			}
+
+			if gotErr != nil {
+				return
+			}
+
+			if got := mapping.override(testCase.args.context); got != testCase.want {
+				t.Errorf("override() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestConfigNamespaces(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		config string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		"none": {
+			args{},
+			nil,
+			false,
+		},
+		"dotted context": {
+			args{
+				config: "namespaces:\n  gke.prod: payments-gke\n  arn:aws:eks:eu-west-1:123456789012:cluster/prod.eu: payments-eu\n",
+			},
+			map[string]string{"gke.prod": "payments-gke", "arn:aws:eks:eu-west-1:123456789012:cluster/prod.eu": "payments-eu"},
+			false,
+		},
+		"not a mapping": {
+			args{
+				config: "namespaces: payments\n",
+			},
+			nil,
+			true,
+		},
+		"not a namespace": {
+			args{
+				config: "namespaces:\n  staging: [payments]\n",
+			},
+			nil,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			config := viper.New()
+			config.SetConfigType("yaml")
+
+			if err := config.ReadConfig(strings.NewReader(testCase.args.config)); err != nil {
+				t.Fatalf("ReadConfig() = %s", err)
+			}
+
+			got, gotErr := configNamespaces(config.Get("namespaces"))
+			if !reflect.DeepEqual(got, testCase.want) || (gotErr != nil) != testCase.wantErr {
+				t.Errorf("configNamespaces() = (%v, `%v`), want (%v, error %t)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
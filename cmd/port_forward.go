@@ -12,43 +12,16 @@ import (
 	"github.com/ViBiOh/kmux/pkg/resource"
 	"github.com/ViBiOh/kmux/pkg/tcpool"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var limiter uint
 
 var portForwardCmd = &cobra.Command{
-	Use:     "port-forward TYPE NAME [local_port:]remote_port",
-	Aliases: []string{"forward"},
-	Short:   "Port forward to pods of a resource",
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			return []string{
-				"daemonsets",
-				"deployments",
-				"pods",
-				"services",
-				"statefulsets",
-			}, cobra.ShellCompDirectiveNoFileComp
-		}
-
-		if len(args) == 1 {
-			lister, err := resource.ListerFor(args[0])
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			clients, err = getKubernetesClient(viper.GetStringSlice("context"))
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			return listObjects(cmd.Context(), viper.GetString("namespace"), lister), cobra.ShellCompDirectiveNoFileComp
-		}
-
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	},
-	Args: cobra.MatchAll(cobra.ExactArgs(3), cobra.OnlyValidArgs),
+	Use:               "port-forward TYPE NAME [local_port:]remote_port",
+	Aliases:           []string{"forward"},
+	Short:             "Port forward to pods of a resource",
+	ValidArgsFunction: completeResource(resource.Forwardable),
+	Args:              cobra.MatchAll(cobra.ExactArgs(3), cobra.OnlyValidArgs),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		kind := args[0]
 		name := args[1]
@@ -71,7 +44,7 @@ var portForwardCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
 
-		output.Std("", "Listening tcp on %d", localPort)
+		output.NewOutputter("").Record(map[string]any{"event": "listening", "local_port": localPort}, "Listening tcp on %d", localPort)
 
 		var pool *tcpool.Pool
 		if !dryRun {
@@ -86,14 +59,14 @@ var portForwardCmd = &cobra.Command{
 
 		forwarder := forward.NewForwarder(kind, name, remotePort, pool, limiter)
 
-		clients.Execute(ctx, forwarder.Forward)
+		report := clients.Unbounded().Execute(ctx, forwarder.Forward)
 		cancel()
 
 		if pool != nil {
 			<-pool.Done()
 		}
 
-		return nil
+		return reportError(cmd, report)
 	},
 }
 
@@ -10,9 +10,6 @@ import (
 	"github.com/ViBiOh/kmux/pkg/client"
 	"github.com/ViBiOh/kmux/pkg/resource"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 )
 
 var user string
@@ -28,35 +25,10 @@ type restartPatch struct {
 }
 
 var restartCmd = &cobra.Command{
-	Use:   "restart TYPE NAME",
-	Short: "Restart the given resource",
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			return []string{
-				"daemonsets",
-				"deployments",
-				"jobs",
-				"statefulsets",
-			}, cobra.ShellCompDirectiveNoFileComp
-		}
-
-		if len(args) == 1 {
-			lister, err := resource.ListerFor(args[0])
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			clients, err = getKubernetesClient(viper.GetStringSlice("context"))
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			return listObjects(cmd.Context(), viper.GetString("namespace"), lister), cobra.ShellCompDirectiveNoFileComp
-		}
-
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	},
-	Args: cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
+	Use:               "restart TYPE NAME",
+	Short:             "Restart the given resource",
+	ValidArgsFunction: completeResource(resource.Restartable),
+	Args:              cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		kind := args[0]
 		name := args[1]
@@ -64,9 +36,11 @@ var restartCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
 
+		restartedAt := time.Now().Format(time.RFC3339)
+
 		var patch restartPatch
 		patch.Spec.Template.Metadata.Annotations = map[string]string{
-			"kmux.vibioh.fr/restartedAt": time.Now().Format(time.RFC3339),
+			"kmux.vibioh.fr/restartedAt": restartedAt,
 		}
 
 		if len(user) != 0 {
@@ -78,40 +52,15 @@ var restartCmd = &cobra.Command{
 			return fmt.Errorf("marshal patch: %w", err)
 		}
 
-		clients.Execute(ctx, func(ctx context.Context, kube client.Kube) error {
-			switch kind {
-			case "ds", "daemonset", "daemonsets":
-				_, err := kube.AppsV1().DaemonSets(kube.Namespace).Patch(ctx, name, types.MergePatchType, payload, v1.PatchOptions{})
-				return err
-			case "deploy", "deployment", "deployments":
-				_, err := kube.AppsV1().Deployments(kube.Namespace).Patch(ctx, name, types.MergePatchType, payload, v1.PatchOptions{})
-				return err
-			case "job", "jobs":
-				job, err := kube.BatchV1().Jobs(kube.Namespace).Get(ctx, name, v1.GetOptions{})
-				if err != nil {
-					return err
-				}
-
-				job.Spec.Selector = nil
-				job.Spec.Template.Labels = nil
-
-				if err = kube.BatchV1().Jobs(kube.Namespace).Delete(ctx, name, v1.DeleteOptions{}); err != nil {
-					return err
-				}
+		return multiplex(ctx, cmd, func(ctx context.Context, kube client.Kube) error {
+			if err := resource.Restart(ctx, kube, kind, name, payload); err != nil {
+				return fmt.Errorf("restart `%s`: %w", kind, err)
+			}
 
-				job.ResourceVersion = ""
+			kube.Record(map[string]any{"kind": kind, "name": name, "restarted_at": restartedAt}, "")
 
-				_, err = kube.BatchV1().Jobs(kube.Namespace).Create(ctx, job, v1.CreateOptions{})
-				return err
-			case "sts", "statefulset", "statefulsets":
-				_, err := kube.AppsV1().StatefulSets(kube.Namespace).Patch(ctx, name, types.MergePatchType, payload, v1.PatchOptions{})
-				return err
-			default:
-				return fmt.Errorf("unhandled resource type `%s` for restart", kind)
-			}
+			return nil
 		})
-
-		return nil
 	},
 }
 
@@ -2,17 +2,23 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/ViBiOh/kmux/pkg/client"
 	"github.com/ViBiOh/kmux/pkg/output"
 	"github.com/ViBiOh/kmux/pkg/resource"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/util/homedir"
@@ -22,14 +28,39 @@ var (
 	clients      client.Array
 	allNamespace bool
 
+	parallelContexts uint
+	contextTimeout   time.Duration
+
+	outputFormat string
+
 	container       string
 	containerRegexp *regexp.Regexp
 )
 
+// newClientset and newDynamicClient create the kubernetes clients for a context, overridable for testing with fake clients
+var (
+	newClientset = func(config *rest.Config) (kubernetes.Interface, error) {
+		return kubernetes.NewForConfig(config)
+	}
+
+	newDynamicClient = func(config *rest.Config) (dynamic.Interface, error) {
+		return dynamic.NewForConfig(config)
+	}
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "kmux",
 	Short: "Multiplexing kubectl common tasks across clusters",
+	// errors are printed once on exit, or already have been for each context
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		switch outputFormat {
+		case "", "wide", output.FormatJSON, output.FormatNDJSON:
+			output.SetFormat(outputFormat)
+		default:
+			return fmt.Errorf("unknown output format `%s`", outputFormat)
+		}
+
 		if cmd.Name() == "version" {
 			return err
 		}
@@ -45,8 +76,8 @@ var rootCmd = &cobra.Command{
 		output.Close()
 		<-output.Done()
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		clients.Execute(cmd.Context(), func(ctx context.Context, kube client.Kube) error {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return multiplex(cmd.Context(), cmd, func(ctx context.Context, kube client.Kube) error {
 			info, err := kube.Discovery().ServerVersion()
 			if err != nil {
 				return fmt.Errorf("get server version: %w", err)
@@ -60,31 +91,89 @@ var rootCmd = &cobra.Command{
 }
 
 func getKubernetesClient(contexts []string) (client.Array, error) {
-	var clientsArray client.Array
+	var kubes []client.Kube
 
 	configRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: viper.GetString("kubeconfig")}
 
+	contexts, err := getContexts(configRules, contexts)
+	if err != nil {
+		return client.Array{}, fmt.Errorf("get contexts: %w", err)
+	}
+
+	config, err := configNamespaces(viper.Get("namespaces"))
+	if err != nil {
+		return client.Array{}, fmt.Errorf("read namespaces: %w", err)
+	}
+
+	namespaces, err := newNamespaceMapping(viper.GetStringSlice("namespace"), config)
+	if err != nil {
+		return client.Array{}, fmt.Errorf("parse namespaces: %w", err)
+	}
+
 	if len(contexts) == 0 {
 		contexts = append(contexts, "")
 	}
 
 	for _, ctx := range contexts {
-		kubeClient, err := getKubeClient(configRules, ctx)
+		kubeClient, err := getKubeClient(configRules, ctx, namespaces)
 		if err != nil {
-			return clientsArray, fmt.Errorf("get kube client: %w", err)
+			return client.Array{}, fmt.Errorf("get kube client: %w", err)
 		}
 
-		clientsArray = append(clientsArray, kubeClient)
+		kubes = append(kubes, kubeClient)
 	}
 
-	return clientsArray, nil
+	return client.NewArray(kubes...).WithParallel(parallelContexts).WithTimeout(contextTimeout), nil
 }
 
-func getKubeClient(configRules clientcmd.ClientConfigLoader, context string) (client.Kube, error) {
+func getContexts(configRules *clientcmd.ClientConfigLoadingRules, contexts []string) ([]string, error) {
+	rawContextRegexp := viper.GetString("contextRegexp")
+
+	if len(rawContextRegexp) == 0 && !slices.ContainsFunc(contexts, func(context string) bool {
+		return isGlob(context) || strings.HasPrefix(context, groupPrefix)
+	}) {
+		return contexts, nil
+	}
+
+	var contextRegexp *regexp.Regexp
+
+	if len(rawContextRegexp) != 0 {
+		var err error
+
+		contextRegexp, err = regexp.Compile(rawContextRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("compile context regexp: %w", err)
+		}
+	}
+
+	config, err := configRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load kubernetes config file: %w", err)
+	}
+
+	available := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		available = append(available, name)
+	}
+
+	return resolveContexts(available, contexts, contextRegexp, viper.GetStringMapStringSlice("groups"))
+}
+
+func getKubeClient(configRules clientcmd.ClientConfigLoader, context string, namespaces namespaceMapping) (client.Kube, error) {
+	contextName := context
+	if len(contextName) == 0 {
+		rawConfig, err := configRules.Load()
+		if err != nil {
+			return client.Kube{}, fmt.Errorf("load kubernetes config file: %w", err)
+		}
+
+		contextName = rawConfig.CurrentContext
+	}
+
 	configOverrides := &clientcmd.ConfigOverrides{
 		CurrentContext: context,
 		Context: api.Context{
-			Namespace: viper.GetString("namespace"),
+			Namespace: namespaces.override(contextName),
 		},
 	}
 
@@ -100,16 +189,21 @@ func getKubeClient(configRules clientcmd.ClientConfigLoader, context string) (cl
 		return client.Kube{}, fmt.Errorf("read configured namespace: %w", err)
 	}
 
-	clientset, err := kubernetes.NewForConfig(k8sConfig)
+	clientset, err := newClientset(k8sConfig)
 	if err != nil {
 		return client.Kube{}, fmt.Errorf("create kubernetes client: %w", err)
 	}
 
+	dynamicClient, err := newDynamicClient(k8sConfig)
+	if err != nil {
+		return client.Kube{}, fmt.Errorf("create kubernetes dynamic client: %w", err)
+	}
+
 	if allNamespace {
 		namespace = ""
 	}
 
-	return client.New(context, namespace, k8sConfig, clientset), nil
+	return client.New(context, namespace, k8sConfig, clientset, dynamicClient), nil
 }
 
 func init() {
@@ -120,14 +214,19 @@ func init() {
 	var defaultConfig string
 	if home := homedir.HomeDir(); home != "" {
 		defaultConfig = filepath.Join(home, ".kube", "config")
+
+		viper.SetConfigName("config")
+		viper.AddConfigPath(filepath.Join(home, ".config", "kmux"))
 	}
 
+	cobra.OnInitialize(readConfig)
+
 	flags.String("kubeconfig", defaultConfig, "Kubernetes configuration file")
 	if err := viper.BindPFlag("kubeconfig", flags.Lookup("kubeconfig")); err != nil {
 		output.Fatal("bind `kubeconfig` flag: %s", err)
 	}
 
-	flags.StringSlice("context", nil, "Kubernetes context, multiple for multiplexing commands")
+	flags.StringSlice("context", nil, "Kubernetes context, glob pattern or @group, multiple for multiplexing commands")
 	if err := viper.BindPFlag("context", flags.Lookup("context")); err != nil {
 		output.Fatal("bind `context` flag: %s", err)
 	}
@@ -136,9 +235,19 @@ func init() {
 		output.Fatal("register `context` flag completion: %s", err)
 	}
 
+	flags.String("context-regexp", "", "Kubernetes contexts matching the regexp, added to the --context ones")
+	if err := viper.BindPFlag("contextRegexp", flags.Lookup("context-regexp")); err != nil {
+		output.Fatal("bind `context-regexp` flag: %s", err)
+	}
+
 	flags.BoolVarP(&allNamespace, "all-namespaces", "A", false, "Find resources in all namespaces")
 
-	flags.StringP("namespace", "n", "", "Override kubernetes namespace in context")
+	flags.StringVarP(&outputFormat, "output", "o", "", "Output format. One of: (json, ndjson, wide for watch)")
+
+	flags.UintVar(&parallelContexts, "parallel", 0, "Maximum number of contexts executed at the same time, 0 for unlimited")
+	flags.DurationVar(&contextTimeout, "context-timeout", 0, "Timeout of the action on each context, 0 for none, ignored when streaming")
+
+	flags.StringSliceP("namespace", "n", nil, "Override kubernetes namespace in context, context=namespace for a given context")
 	if err := viper.BindPFlag("namespace", flags.Lookup("namespace")); err != nil {
 		output.Fatal("bind `namespace` flag: %s", err)
 	}
@@ -171,7 +280,16 @@ func init() {
 	rootCmd.AddCommand(scaleCmd)
 }
 
-func completeContext(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+func readConfig() {
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			output.Fatal("read kmux config file: %s", err)
+		}
+	}
+}
+
+func completeContext(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	configRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: viper.GetString("kubeconfig")}
 	config, err := configRules.Load()
 	if err != nil {
@@ -188,9 +306,25 @@ func completeContext(_ *cobra.Command, _ []string, _ string) ([]string, cobra.Sh
 		completeContexts = append(completeContexts, name)
 	}
 
+	for name := range viper.GetStringMapStringSlice("groups") {
+		if contains(contexts, groupPrefix+name) {
+			continue
+		}
+		completeContexts = append(completeContexts, completeGroup(groupPrefix+name, toComplete))
+	}
+
 	return completeContexts, cobra.ShellCompDirectiveNoFileComp
 }
 
+// completeGroup keeps the spelling being typed, the group names being lowercased by viper and the shell filtering completions case-sensitively
+func completeGroup(group, toComplete string) string {
+	if len(toComplete) <= len(group) && strings.EqualFold(group[:len(toComplete)], toComplete) {
+		return toComplete + group[len(toComplete):]
+	}
+
+	return group
+}
+
 func completeNamespace(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
 	lister, err := resource.ListerFor("namespace")
 	if err != nil {
@@ -202,7 +336,7 @@ func completeNamespace(cmd *cobra.Command, _ []string, _ string) ([]string, cobr
 		return nil, cobra.ShellCompDirectiveError
 	}
 
-	return listObjects(cmd.Context(), "", lister), cobra.ShellCompDirectiveDefault
+	return listObjects(cmd.Context(), lister), cobra.ShellCompDirectiveDefault
 }
 
 func contains(arr []string, value string) bool {
@@ -216,7 +350,27 @@ func contains(arr []string, value string) bool {
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		output.Fatal("%s", err)
+	err := rootCmd.Execute()
+
+	output.Close()
+	<-output.Done()
+
+	if errors.Is(err, client.ErrReported) {
+		os.Exit(exitCode(err))
+	}
+
+	if err != nil {
+		output.Exit(exitCode(err), "%s\n", err)
+	}
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, client.ErrTotalFailure):
+		return 3
+	case errors.Is(err, client.ErrPartialFailure):
+		return 2
+	default:
+		return 1
 	}
 }
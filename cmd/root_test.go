@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func useFakeKubeconfig(t *testing.T, current string, contexts map[string]string) {
+	t.Helper()
+
+	config := api.NewConfig()
+	config.CurrentContext = current
+
+	for name, namespace := range contexts {
+		config.Clusters[name] = &api.Cluster{Server: "https://" + name + ".kmux.test"}
+		config.AuthInfos[name] = &api.AuthInfo{Token: name}
+		config.Contexts[name] = &api.Context{Cluster: name, AuthInfo: name, Namespace: namespace}
+	}
+
+	filename := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*config, filename); err != nil {
+		t.Fatalf("write kubeconfig: %s", err)
+	}
+
+	previousClientset := newClientset
+	newClientset = func(_ *rest.Config) (kubernetes.Interface, error) {
+		return fake.NewClientset(), nil
+	}
+
+	viper.Set("kubeconfig", filename)
+
+	t.Cleanup(func() {
+		newClientset = previousClientset
+		viper.Set("kubeconfig", "")
+		viper.Set("namespace", nil)
+	})
+}
+
+func TestGetKubernetesClient(t *testing.T) {
+	useFakeKubeconfig(t, "staging", map[string]string{
+		"prod-eu": "payments-eu",
+		"prod-us": "payments",
+		"staging": "default",
+	})
+
+	type args struct {
+		contexts  []string
+		namespace []string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		"current": {
+			args{},
+			[]string{"/default"},
+			false,
+		},
+		"multiple": {
+			args{
+				contexts: []string{"prod-eu", "prod-us"},
+			},
+			[]string{"prod-eu/payments-eu", "prod-us/payments"},
+			false,
+		},
+		"glob": {
+			args{
+				contexts: []string{"prod-*"},
+			},
+			[]string{"prod-eu/payments-eu", "prod-us/payments"},
+			false,
+		},
+		"namespace override": {
+			args{
+				contexts:  []string{"prod-eu", "prod-us"},
+				namespace: []string{"monitoring"},
+			},
+			[]string{"prod-eu/monitoring", "prod-us/monitoring"},
+			false,
+		},
+		"namespace mapping": {
+			args{
+				contexts:  []string{"prod-eu", "prod-us", "staging"},
+				namespace: []string{"prod-*=api", "prod-eu=api-eu"},
+			},
+			[]string{"prod-eu/api-eu", "prod-us/api", "staging/default"},
+			false,
+		},
+		"current namespace mapping": {
+			args{
+				namespace: []string{"monitoring", "staging=api"},
+			},
+			[]string{"/api"},
+			false,
+		},
+		"unknown": {
+			args{
+				contexts: []string{"dev"},
+			},
+			nil,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			viper.Set("namespace", testCase.args.namespace)
+
+			clients, gotErr := getKubernetesClient(testCase.args.contexts)
+
+			var got []string
+			for _, kube := range clients.Kubes() {
+				got = append(got, kube.Name+"/"+kube.Namespace)
+			}
+
+			if (gotErr != nil) != testCase.wantErr || !slices.Equal(got, testCase.want) {
+				t.Errorf("getKubernetesClient() = (%v, `%v`), want (%v, error %t)", got, gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
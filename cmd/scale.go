@@ -9,8 +9,6 @@ import (
 	"github.com/ViBiOh/kmux/pkg/client"
 	"github.com/ViBiOh/kmux/pkg/resource"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
@@ -19,34 +17,10 @@ var (
 )
 
 var scaleCmd = &cobra.Command{
-	Use:   "scale TYPE NAME",
-	Short: "Scale a resource by a given factor",
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			return []string{
-				"deployments",
-				"replicasets",
-				"statefulsets",
-			}, cobra.ShellCompDirectiveNoFileComp
-		}
-
-		if len(args) == 1 {
-			lister, err := resource.ListerFor(args[0])
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			clients, err = getKubernetesClient(viper.GetStringSlice("context"))
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveError
-			}
-
-			return listObjects(cmd.Context(), viper.GetString("namespace"), lister), cobra.ShellCompDirectiveNoFileComp
-		}
-
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	},
-	Args: cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
+	Use:               "scale TYPE NAME",
+	Short:             "Scale a resource by a given factor",
+	ValidArgsFunction: completeResource(resource.Scalable),
+	Args:              cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		kind := args[0]
 		name := args[1]
@@ -63,7 +37,7 @@ var scaleCmd = &cobra.Command{
 			return errors.New("use `--force` to confirm downscaling to zero pods")
 		}
 
-		clients.Execute(ctx, func(ctx context.Context, kube client.Kube) error {
+		return multiplex(ctx, cmd, func(ctx context.Context, kube client.Kube) error {
 			scale, err := resource.GetScale(ctx, kube, kind, name)
 			if err != nil {
 				return err
@@ -72,29 +46,26 @@ var scaleCmd = &cobra.Command{
 			oldReplicas := scale.Spec.Replicas
 			scale.Spec.Replicas = int32(math.Ceil(float64(max(1, oldReplicas)) * scaleFactor))
 
+			record := map[string]any{
+				"kind":              kind,
+				"name":              name,
+				"previous_replicas": oldReplicas,
+				"replicas":          scale.Spec.Replicas,
+			}
+
 			if oldReplicas == scale.Spec.Replicas {
-				kube.Std("No replica change from %d", scale.Spec.Replicas)
+				kube.Record(record, "No replica change from %d", scale.Spec.Replicas)
 				return nil
 			}
 
-			kube.Std("Scale from %d to %d", oldReplicas, scale.Spec.Replicas)
-
-			switch kind {
-			case "deploy", "deployment", "deployments":
-				_, err := kube.AppsV1().Deployments(kube.Namespace).UpdateScale(ctx, name, scale, v1.UpdateOptions{})
-				return err
-			case "rs", "replicaset", "replicasets":
-				_, err := kube.AppsV1().ReplicaSets(kube.Namespace).UpdateScale(ctx, name, scale, v1.UpdateOptions{})
-				return err
-			case "sts", "statefulset", "statefulsets":
-				_, err := kube.AppsV1().StatefulSets(kube.Namespace).UpdateScale(ctx, name, scale, v1.UpdateOptions{})
+			if err := resource.UpdateScale(ctx, kube, kind, name, scale); err != nil {
 				return err
 			}
 
+			kube.Record(record, "Scale from %d to %d", oldReplicas, scale.Spec.Replicas)
+
 			return nil
 		})
-
-		return nil
 	},
 }
 
@@ -1,10 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"os/signal"
+
+	"github.com/ViBiOh/kmux/pkg/client"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
+func validateSelector(selector string) error {
+	if _, err := labels.Parse(selector); err != nil {
+		return fmt.Errorf("parse selector: %w", err)
+	}
+
+	return nil
+}
+
 func waitForEnd(signals ...os.Signal) {
 	signalsChan := make(chan os.Signal, len(signals))
 	defer close(signalsChan)
@@ -14,3 +28,20 @@ func waitForEnd(signals ...os.Signal) {
 
 	<-signalsChan
 }
+
+// multiplex executes the action on every client, summarizes the outcome and returns an error if any context failed
+func multiplex(ctx context.Context, cmd *cobra.Command, action client.Action) error {
+	return reportError(cmd, clients.Execute(ctx, action))
+}
+
+func reportError(cmd *cobra.Command, report client.Report) error {
+	report.Summarize()
+
+	if err := report.Err(); err != nil {
+		cmd.SilenceUsage = true
+
+		return err
+	}
+
+	return nil
+}
@@ -15,6 +15,6 @@ var versionCmd = &cobra.Command{
 			Version = "(devel)"
 		}
 
-		output.Std("", "%s", Version)
+		output.NewOutputter("").Record(map[string]any{"version": Version}, "%s", Version)
 	},
 }
@@ -28,7 +28,6 @@ type watchPod struct {
 }
 
 var (
-	outputFormat    string
 	showLabels      bool
 	showAnnotations bool
 	labelColumns    []string
@@ -37,8 +36,7 @@ var (
 func initWatch() {
 	flags := watchCmd.Flags()
 
-	flags.StringVarP(&outputFormat, "output", "o", "", "Output format. One of: (wide)")
-	flags.StringToStringVarP(&labelsSelector, "selector", "l", nil, "Labels to filter pods")
+	flags.StringVarP(&labelsSelector, "selector", "l", "", "Labels selector to filter pods, e.g. app=api,tier in (web,worker)")
 	flags.BoolVarP(&showLabels, "show-labels", "", false, "Show all labels as the last column")
 	flags.BoolVarP(&showAnnotations, "show-annotations", "", false, "Show all annotations as the last column (after labels if both asked)")
 	flags.StringSliceVarP(&labelColumns, "label-columns", "L", nil, "Labels that are going to be presented as columns")
@@ -47,7 +45,11 @@ func initWatch() {
 var watchCmd = &cobra.Command{
 	Use:   "watch",
 	Short: "Get all pods in the namespace",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateSelector(labelsSelector); err != nil {
+			return err
+		}
+
 		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
 
@@ -59,7 +61,7 @@ var watchCmd = &cobra.Command{
 		watchTable := initWatchTable()
 		initialsPodsHash := displayInitialPods(ctx, watchTable)
 
-		clients.Execute(ctx, func(ctx context.Context, kube client.Kube) error {
+		return reportError(cmd, clients.Unbounded().Execute(ctx, func(ctx context.Context, kube client.Kube) error {
 			watcher, err := resource.WatchPods(ctx, kube, "namespace", kube.Namespace, labelsSelector, false)
 			if err != nil {
 				return fmt.Errorf("watch pods: %w", err)
@@ -81,7 +83,7 @@ var watchCmd = &cobra.Command{
 			}
 
 			return nil
-		})
+		}))
 	},
 }
 
@@ -102,9 +104,9 @@ func initWatchTable() *table.Table {
 		content = append([]table.Cell{table.NewCell("NAMESPACE")}, content...)
 	}
 
-	if len(clients) > 0 && len(clients[0].Name) != 0 {
+	if kubes := clients.Kubes(); len(kubes) > 0 && len(kubes[0].Name) != 0 {
 		var maxContextWidth uint64
-		for _, c := range clients {
+		for _, c := range kubes {
 			if w := uint64(len(c.Name)); w > maxContextWidth {
 				maxContextWidth = w
 			}
@@ -141,7 +143,10 @@ func initWatchTable() *table.Table {
 	}
 
 	watchTable := table.New(defaultWidths)
-	output.Std("", "%s", watchTable.Format(content))
+
+	if !output.IsStructured() {
+		output.Std("", "%s", watchTable.Format(content))
+	}
 
 	return watchTable
 }
@@ -207,6 +212,11 @@ func (a PodByAge) Less(i, j int) bool {
 }
 
 func outputWatch(watchTable *table.Table, contextName string, pod v1.Pod) {
+	if output.IsStructured() {
+		recordWatch(contextName, pod)
+		return
+	}
+
 	var content []table.Cell
 
 	if len(contextName) != 0 {
@@ -276,6 +286,37 @@ func outputWatch(watchTable *table.Table, contextName string, pod v1.Pod) {
 	output.Std("", "%s", watchTable.Format(content))
 }
 
+func recordWatch(contextName string, pod v1.Pod) {
+	phase, ready, total, restart, lastRestartDate := getPodStatus(pod)
+	ip, node, nominatedNode, readinessGates := getPodWide(pod)
+
+	record := map[string]any{
+		"phase":           phase,
+		"ready":           ready,
+		"total":           total,
+		"restarts":        restart,
+		"ip":              ip,
+		"node":            node,
+		"nominated_node":  nominatedNode,
+		"readiness_gates": readinessGates,
+		"labels":          pod.GetLabels(),
+	}
+
+	if pod.Status.StartTime != nil {
+		record["start_time"] = pod.Status.StartTime.Time
+	}
+
+	if !lastRestartDate.IsZero() {
+		record["last_restart"] = lastRestartDate
+	}
+
+	if showAnnotations {
+		record["annotations"] = pod.GetAnnotations()
+	}
+
+	output.NewOutputter(contextName).WithMeta(output.Meta{Namespace: pod.Namespace, Pod: pod.Name}).Record(record, "")
+}
+
 func getPhaseCell(phase string) table.Cell {
 	switch phase {
 	case string(v1.PodRunning), string(v1.PodSucceeded), "Completed":
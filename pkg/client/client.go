@@ -2,45 +2,228 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ViBiOh/kmux/pkg/output"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+var (
+	// ErrReported is wrapped by the errors of a report, already printed for each context when they occurred
+	ErrReported       = errors.New("reported")
+	ErrPartialFailure = fmt.Errorf("partial failure: %w", ErrReported)
+	ErrTotalFailure   = fmt.Errorf("total failure: %w", ErrReported)
+	ErrTimeout        = errors.New("timeout")
 )
 
 type Kube struct {
 	output.Outputter
-	*kubernetes.Clientset
-	Config    *rest.Config
-	Name      string
-	Namespace string
+	kubernetes.Interface
+	Dynamic         dynamic.Interface
+	CachedDiscovery discovery.CachedDiscoveryInterface
+	Mapper          meta.ResettableRESTMapper // lazily discovers the resources once, reset when a kind is not found
+	Config          *rest.Config
+	Name            string
+	Namespace       string
 }
 
-func New(name, namespace string, config *rest.Config, clientset *kubernetes.Clientset) Kube {
-	return Kube{
-		Outputter: output.NewOutputter(name),
-		Clientset: clientset,
+func New(name, namespace string, config *rest.Config, clientset kubernetes.Interface, dynamicClient dynamic.Interface) Kube {
+	kube := Kube{
+		Outputter: output.NewOutputter(name).WithMeta(output.Meta{Namespace: namespace}),
+		Interface: clientset,
+		Dynamic:   dynamicClient,
 		Config:    config,
 		Name:      name,
 		Namespace: namespace,
 	}
+
+	if clientset != nil {
+		kube.CachedDiscovery = memory.NewMemCacheClient(clientset.Discovery())
+		kube.Mapper = restmapper.NewDeferredDiscoveryRESTMapper(kube.CachedDiscovery)
+	}
+
+	return kube
 }
 
 type Action func(context.Context, Kube) error
 
-type Array []Kube
+type Result struct {
+	Err      error
+	Context  string
+	Duration time.Duration
+}
+
+type Report []Result
+
+func (r Report) Failed() int {
+	var count int
+
+	for _, result := range r {
+		if result.Err != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (r Report) Err() error {
+	failed := r.Failed()
+
+	switch {
+	case failed == 0:
+		return nil
+	case failed == len(r):
+		return fmt.Errorf("%d/%d contexts failed: %w", failed, len(r), ErrTotalFailure)
+	default:
+		return fmt.Errorf("%d/%d contexts failed: %w", failed, len(r), ErrPartialFailure)
+	}
+}
+
+// Summarize outputs the status of every context, only when multiplexing or when something failed, errors being printed when they occur
+func (r Report) Summarize() {
+	failed := r.Failed()
+	if len(r) < 2 && failed == 0 {
+		return
+	}
+
+	output.Info("", "%s", output.Yellow.Sprintf("%d succeeded, %d failed", len(r)-failed, failed))
+
+	for _, result := range r {
+		outputter := output.NewOutputter(result.Context)
+		duration := result.Duration.Round(time.Millisecond)
+
+		if result.Err != nil {
+			outputter.Err("failed in %s", duration)
+		} else {
+			outputter.Info("%s", output.Green.Sprintf("succeeded in %s", duration))
+		}
+	}
+}
+
+type Array struct {
+	kubes    []Kube
+	timeout  time.Duration
+	parallel uint
+}
+
+func NewArray(kubes ...Kube) Array {
+	return Array{
+		kubes: kubes,
+	}
+}
+
+// WithParallel bounds the number of contexts executed at the same time, zero means unbounded
+func (a Array) WithParallel(parallel uint) Array {
+	a.parallel = parallel
+
+	return a
+}
+
+// WithTimeout bounds the duration of the action on each context, zero means no timeout
+func (a Array) WithTimeout(timeout time.Duration) Array {
+	a.timeout = timeout
 
-func (a Array) Execute(ctx context.Context, action Action) {
+	return a
+}
+
+// Unbounded removes parallel and timeout limits, for long-running actions like streaming
+func (a Array) Unbounded() Array {
+	return a.WithParallel(0).WithTimeout(0)
+}
+
+func (a Array) Len() int {
+	return len(a.kubes)
+}
+
+func (a Array) Kubes() []Kube {
+	return a.kubes
+}
+
+func (a Array) Execute(ctx context.Context, action Action) Report {
 	var parallel sync.WaitGroup
 
-	for _, client := range a {
+	var limiter chan struct{}
+	if a.parallel > 0 {
+		limiter = make(chan struct{}, a.parallel)
+	}
+
+	report := make(Report, len(a.kubes))
+
+	for index, client := range a.kubes {
 		parallel.Go(func() {
-			if err := action(ctx, client); err != nil {
-				client.Err("%s", err)
-			}
+			report[index] = a.execute(ctx, limiter, client, action)
 		})
 	}
 
 	parallel.Wait()
+
+	return report
+}
+
+func (a Array) execute(ctx context.Context, limiter chan struct{}, client Kube, action Action) Result {
+	result := Result{
+		Context: client.Name,
+	}
+
+	if limiter != nil {
+		select {
+		case limiter <- struct{}{}:
+			defer func() { <-limiter }()
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			return result
+		}
+	}
+
+	start := time.Now()
+	result.Err = a.run(ctx, client, action)
+	result.Duration = time.Since(start)
+
+	if result.Err != nil {
+		client.Err("%s", result.Err)
+	}
+
+	return result
+}
+
+func (a Array) run(ctx context.Context, client Kube, action Action) error {
+	if a.timeout == 0 {
+		return action(ctx, client)
+	}
+
+	// the action's requests are aborted by the cancelled context, but calls ignoring it, e.g. discovery, are not waited for past the timeout
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- action(ctx, client)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %s: %w", ErrTimeout, a.timeout, err)
+		}
+
+		return err
+
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return <-done
+		}
+
+		return fmt.Errorf("%w after %s", ErrTimeout, a.timeout)
+	}
 }
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecute(t *testing.T) {
+	t.Parallel()
+
+	errUnreachable := errors.New("unreachable")
+
+	clients := NewArray(
+		New("prod-eu", "default", nil, nil, nil),
+		New("prod-us", "default", nil, nil, nil),
+		New("staging", "default", nil, nil, nil),
+	)
+
+	cases := map[string]struct {
+		action  Action
+		want    int
+		wantErr error
+	}{
+		"success": {
+			func(_ context.Context, _ Kube) error {
+				return nil
+			},
+			0,
+			nil,
+		},
+		"partial": {
+			func(_ context.Context, kube Kube) error {
+				if kube.Name == "prod-us" {
+					return errUnreachable
+				}
+
+				return nil
+			},
+			1,
+			ErrPartialFailure,
+		},
+		"total": {
+			func(_ context.Context, _ Kube) error {
+				return errUnreachable
+			},
+			3,
+			ErrTotalFailure,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			report := clients.Execute(context.Background(), testCase.action)
+
+			if got := report.Failed(); got != testCase.want {
+				t.Errorf("Failed() = %d, want %d", got, testCase.want)
+			}
+
+			if gotErr := report.Err(); !errors.Is(gotErr, testCase.wantErr) || (gotErr == nil) != (testCase.wantErr == nil) {
+				t.Errorf("Err() = `%v`, want `%v`", gotErr, testCase.wantErr)
+			}
+
+			if gotErr := report.Err(); gotErr != nil && !errors.Is(gotErr, ErrReported) {
+				t.Errorf("Err() = `%v`, want it reported", gotErr)
+			}
+
+			for index, result := range report {
+				if result.Context != clients.Kubes()[index].Name {
+					t.Errorf("Execute()[%d] = `%s`, want `%s`", index, result.Context, clients.Kubes()[index].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteLimits(t *testing.T) {
+	t.Parallel()
+
+	var running, maxRunning atomic.Int32
+
+	clients := NewArray(
+		New("prod-eu", "default", nil, nil, nil),
+		New("prod-us", "default", nil, nil, nil),
+		New("staging", "default", nil, nil, nil),
+	).WithParallel(2).WithTimeout(100 * time.Millisecond)
+
+	report := clients.Execute(context.Background(), func(ctx context.Context, kube Kube) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+
+		if kube.Name == "staging" {
+			// simulate an unreachable cluster, the request being cancelled by the context
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+
+		return nil
+	})
+
+	if got := maxRunning.Load(); got > 2 {
+		t.Errorf("Execute() ran %d contexts at once, want at most 2", got)
+	}
+
+	if got := report.Failed(); got != 1 {
+		t.Errorf("Failed() = %d, want 1", got)
+	}
+
+	if got := report[2].Err; !errors.Is(got, ErrTimeout) {
+		t.Errorf("Execute()[2] = `%v`, want `%s`", got, ErrTimeout)
+	}
+}
+
+func TestExecuteTimeoutIgnored(t *testing.T) {
+	t.Parallel()
+
+	clients := NewArray(
+		New("prod-eu", "default", nil, nil, nil),
+		New("prod-us", "default", nil, nil, nil),
+	).WithTimeout(50 * time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+
+	report := clients.Execute(context.Background(), func(_ context.Context, kube Kube) error {
+		if kube.Name == "prod-us" {
+			// simulate an unreachable cluster on a call ignoring the context, e.g. discovery
+			<-release
+		}
+
+		return nil
+	})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Execute() took %s, want it bounded by the timeout", elapsed)
+	}
+
+	if got := report[0].Err; got != nil {
+		t.Errorf("Execute()[0] = `%v`, want nil", got)
+	}
+
+	if got := report[1].Err; !errors.Is(got, ErrTimeout) {
+		t.Errorf("Execute()[1] = `%v`, want `%s`", got, ErrTimeout)
+	}
+}
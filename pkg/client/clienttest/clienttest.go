@@ -0,0 +1,73 @@
+// Package clienttest provides fake kubernetes contexts for testing multiplexed commands without a live cluster.
+package clienttest
+
+import (
+	"github.com/ViBiOh/kmux/pkg/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// coreResources are always discovered, as on a real cluster, the cached discovery considering a context without any group as not fetched yet
+var coreResources = &metav1.APIResourceList{
+	GroupVersion: "v1",
+	APIResources: []metav1.APIResource{
+		{Name: "pods", SingularName: "pod", ShortNames: []string{"po"}, Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list", "watch"}},
+	},
+}
+
+// Context describes a fake kubernetes context and the objects it holds. DynamicObjects are served by the dynamic client for the discovered Resources.
+type Context struct {
+	Name           string
+	Namespace      string
+	Objects        []runtime.Object
+	DynamicObjects []runtime.Object
+	Resources      []*metav1.APIResourceList
+}
+
+func New(name, namespace string, objects ...runtime.Object) client.Kube {
+	return NewContext(Context{Name: name, Namespace: namespace, Objects: objects})
+}
+
+func NewContext(context Context) client.Kube {
+	clientset := fake.NewClientset(context.Objects...)
+	clientset.Discovery().(*fakeDiscovery.FakeDiscovery).Resources = append([]*metav1.APIResourceList{coreResources}, context.Resources...)
+
+	listKinds := make(map[schema.GroupVersionResource]string)
+
+	for _, list := range context.Resources {
+		groupVersion, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range list.APIResources {
+			listKinds[groupVersion.WithResource(resource.Name)] = resource.Kind + "List"
+		}
+	}
+
+	dynamicClient := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, context.DynamicObjects...)
+
+	return client.New(context.Name, context.Namespace, &rest.Config{Host: "https://" + context.Name + ".kmux.test"}, clientset, dynamicClient)
+}
+
+func NewArray(contexts ...Context) client.Array {
+	kubes := make([]client.Kube, len(contexts))
+
+	for index, context := range contexts {
+		kubes[index] = NewContext(context)
+	}
+
+	return client.NewArray(kubes...)
+}
+
+// Fake returns the underlying fake clientset of a client created by this package, e.g. for adding reactors.
+func Fake(kube client.Kube) *fake.Clientset {
+	clientset, _ := kube.Interface.(*fake.Clientset)
+
+	return clientset
+}
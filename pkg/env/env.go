@@ -103,18 +103,24 @@ func (eg EnvGetter) Get(ctx context.Context, kube client.Kube) error {
 		}
 
 		containerOutput := &strings.Builder{}
+		sources := make([]map[string]any, len(values))
 
-		for _, value := range values {
+		for index, value := range values {
 			fmt.Fprintf(containerOutput, "%s", value)
+
+			sources[index] = map[string]any{
+				"source": value.source,
+				"values": value.data,
+			}
 		}
 
-		outputter := kube.Outputter
+		outputter := kube.WithMeta(output.Meta{Pod: mostLivePod.Name, Container: container.Name})
 
 		if len(containers) != 1 {
-			outputter = kube.Child(false, output.Green.Sprintf("[%s]", container.Name))
+			outputter = outputter.Child(false, output.Green.Sprintf("[%s]", container.Name))
 		}
 
-		outputter.Std("%s", containerOutput.String())
+		outputter.Record(map[string]any{"env": sources}, "%s", containerOutput.String())
 	}
 
 	return nil
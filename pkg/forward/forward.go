@@ -64,7 +64,7 @@ func (f Forwarder) Forward(ctx context.Context, kube client.Kube) error {
 		}
 	}
 
-	podWatcher, err := resource.WatchPods(ctx, kube, f.kind, f.name, nil, f.dryRun)
+	podWatcher, err := resource.WatchPods(ctx, kube, f.kind, f.name, "", f.dryRun)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,205 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+)
+
+const alertActionTimeout = 30 * time.Second
+
+// Alert describes the log line that triggered an alert, sent as is to the webhook
+type Alert struct {
+	Timestamp  time.Time `json:"timestamp,omitzero"`
+	Context    string    `json:"context,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Pod        string    `json:"pod,omitempty"`
+	Container  string    `json:"container,omitempty"`
+	Pattern    string    `json:"pattern"`
+	Line       string    `json:"line"`
+	Suppressed uint      `json:"suppressed"`
+}
+
+// Alerter runs a command and/or calls a webhook when a log line matches one of its patterns, at most once per interval for a given pattern
+type Alerter struct {
+	client     *http.Client
+	last       map[string]time.Time
+	suppressed map[string]uint
+	command    string
+	webhook    string
+	patterns   []*regexp.Regexp
+	interval   time.Duration
+	running    sync.WaitGroup
+	mutex      sync.Mutex
+}
+
+func NewAlerter(patterns []*regexp.Regexp, interval time.Duration) *Alerter {
+	return &Alerter{
+		patterns:   patterns,
+		interval:   interval,
+		client:     &http.Client{Timeout: alertActionTimeout},
+		last:       make(map[string]time.Time),
+		suppressed: make(map[string]uint),
+	}
+}
+
+// WithCommand runs the command with `sh -c`, the alert being given in `KMUX_ALERT_*` environment variables
+func (a *Alerter) WithCommand(command string) *Alerter {
+	a.command = command
+
+	return a
+}
+
+// WithWebhook POSTs the alert as JSON to the URL
+func (a *Alerter) WithWebhook(url string) *Alerter {
+	a.webhook = url
+
+	return a
+}
+
+// Check triggers the actions in background when the text matches a pattern that has not alerted during the interval
+func (a *Alerter) Check(outputter output.Outputter, timestamp time.Time, text string) {
+	pattern := matchAny(a.patterns, text)
+	if pattern == nil {
+		return
+	}
+
+	suppressed, ok := a.allow(pattern.String(), time.Now())
+	if !ok {
+		return
+	}
+
+	meta := outputter.Meta()
+
+	alert := Alert{
+		Timestamp:  timestamp,
+		Context:    meta.Context,
+		Namespace:  meta.Namespace,
+		Pod:        meta.Pod,
+		Container:  meta.Container,
+		Pattern:    pattern.String(),
+		Line:       text,
+		Suppressed: suppressed,
+	}
+
+	outputter.Warn("Alert on `%s`", alert.Pattern)
+
+	a.running.Go(func() {
+		a.trigger(outputter, alert)
+	})
+}
+
+// Close waits for the running actions
+func (a *Alerter) Close() {
+	a.running.Wait()
+}
+
+// allow returns the number of alerts suppressed since the last one of the pattern, and if it can alert again
+func (a *Alerter) allow(pattern string, now time.Time) (uint, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if last, ok := a.last[pattern]; ok && now.Sub(last) < a.interval {
+		a.suppressed[pattern]++
+
+		return 0, false
+	}
+
+	suppressed := a.suppressed[pattern]
+
+	a.last[pattern] = now
+	delete(a.suppressed, pattern)
+
+	return suppressed, true
+}
+
+func (a *Alerter) trigger(outputter output.Outputter, alert Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), alertActionTimeout)
+	defer cancel()
+
+	if len(a.command) != 0 {
+		if err := a.run(ctx, alert); err != nil {
+			outputter.Err("alert command: %s", err)
+		}
+	}
+
+	if len(a.webhook) != 0 {
+		if err := a.post(ctx, alert); err != nil {
+			outputter.Err("alert webhook: %s", err)
+		}
+	}
+}
+
+func (a *Alerter) run(ctx context.Context, alert Alert) error {
+	command := exec.CommandContext(ctx, "sh", "-c", a.command)
+	command.Env = append(os.Environ(),
+		"KMUX_ALERT_CONTEXT="+alert.Context,
+		"KMUX_ALERT_NAMESPACE="+alert.Namespace,
+		"KMUX_ALERT_POD="+alert.Pod,
+		"KMUX_ALERT_CONTAINER="+alert.Container,
+		"KMUX_ALERT_PATTERN="+alert.Pattern,
+		"KMUX_ALERT_LINE="+alert.Line,
+		fmt.Sprintf("KMUX_ALERT_SUPPRESSED=%d", alert.Suppressed),
+	)
+
+	if !alert.Timestamp.IsZero() {
+		command.Env = append(command.Env, "KMUX_ALERT_TIMESTAMP="+alert.Timestamp.Format(time.RFC3339Nano))
+	}
+
+	if content, err := command.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(content))
+	}
+
+	return nil
+}
+
+func (a *Alerter) post(ctx context.Context, alert Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.webhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	defer func() {
+		_, _ = io.Copy(io.Discard, response.Body)
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// matchAny returns the first regexp matching the text, nil if none
+func matchAny(regexes []*regexp.Regexp, text string) *regexp.Regexp {
+	for _, candidate := range regexes {
+		if candidate.MatchString(text) {
+			return candidate
+		}
+	}
+
+	return nil
+}
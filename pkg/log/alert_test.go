@@ -0,0 +1,124 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+)
+
+func TestAlerter(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		lines    []string
+		interval time.Duration
+	}
+
+	cases := map[string]struct {
+		args args
+		want []Alert
+	}{
+		"no match": {
+			args{
+				lines:    []string{"Starting server", "Listening on :8080"},
+				interval: time.Hour,
+			},
+			nil,
+		},
+		"match": {
+			args{
+				lines:    []string{"Starting server", "panic: nil map"},
+				interval: time.Hour,
+			},
+			[]Alert{
+				{Pod: "api-1", Container: "api", Pattern: "panic|OOMKilled", Line: "panic: nil map"},
+			},
+		},
+		"rate limited": {
+			args{
+				lines:    []string{"panic: nil map", "panic: nil map", "OOMKilled", "connection refused"},
+				interval: time.Hour,
+			},
+			[]Alert{
+				{Pod: "api-1", Container: "api", Pattern: "panic|OOMKilled", Line: "panic: nil map"},
+				{Pod: "api-1", Container: "api", Pattern: "connection refused", Line: "connection refused"},
+			},
+		},
+		"no interval": {
+			args{
+				lines:    []string{"panic: nil map", "panic: nil map", "panic: nil map"},
+				interval: 0,
+			},
+			[]Alert{
+				{Pod: "api-1", Container: "api", Pattern: "panic|OOMKilled", Line: "panic: nil map"},
+				{Pod: "api-1", Container: "api", Pattern: "panic|OOMKilled", Line: "panic: nil map"},
+				{Pod: "api-1", Container: "api", Pattern: "panic|OOMKilled", Line: "panic: nil map"},
+			},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			var got []Alert
+			var mutex sync.Mutex
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var alert Alert
+				if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				mutex.Lock()
+				got = append(got, alert)
+				mutex.Unlock()
+			}))
+			defer server.Close()
+
+			alerter := NewAlerter([]*regexp.Regexp{regexp.MustCompile("panic|OOMKilled"), regexp.MustCompile("connection refused")}, testCase.args.interval).WithWebhook(server.URL)
+			outputter := output.NewOutputter("").WithMeta(output.Meta{Pod: "api-1", Container: "api"})
+
+			for _, line := range testCase.args.lines {
+				alerter.Check(outputter, time.Time{}, line)
+				alerter.Close()
+			}
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Check() = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestAlerterAllow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	alerter := NewAlerter(nil, time.Minute)
+
+	for index, step := range []struct {
+		at             time.Time
+		wantSuppressed uint
+		want           bool
+	}{
+		{now, 0, true},
+		{now.Add(time.Second), 0, false},
+		{now.Add(30 * time.Second), 0, false},
+		{now.Add(time.Minute), 2, true},
+		{now.Add(time.Minute + time.Second), 0, false},
+	} {
+		if gotSuppressed, got := alerter.allow("panic", step.at); got != step.want || gotSuppressed != step.wantSuppressed {
+			t.Errorf("allow() #%d = (%d, %t), want (%d, %t)", index, gotSuppressed, got, step.wantSuppressed, step.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+)
+
+const defaultArchiveContext = "default"
+
+type archiveFile struct {
+	file   *os.File
+	path   string
+	size   int64
+	failed bool
+	mutex  sync.Mutex
+}
+
+// Archive writes the printed logs to one file per context, namespace, pod and container, rotated once above the max size
+type Archive struct {
+	files     map[string]*archiveFile
+	directory string
+	maxSize   int64
+	backups   int
+	mutex     sync.Mutex
+}
+
+// NewArchive creates the directory, a max size of 0 disables the rotation and backups are the number of rotated files kept
+func NewArchive(directory string, maxSize int64, backups int) (*Archive, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+
+	return &Archive{
+		directory: directory,
+		maxSize:   maxSize,
+		backups:   max(backups, 0),
+		files:     make(map[string]*archiveFile),
+	}, nil
+}
+
+// Write appends the line to the file of its origin. Only the first error of a file is returned, the file being skipped afterward.
+func (a *Archive) Write(meta output.Meta, line string) error {
+	file := a.fileOf(meta)
+
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+
+	if file.failed {
+		return nil
+	}
+
+	if err := a.write(file, line+"\n"); err != nil {
+		file.failed = true
+
+		return fmt.Errorf("write `%s`: %w", file.path, err)
+	}
+
+	return nil
+}
+
+// Close closes every file, it must be called once all logs are written
+func (a *Archive) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var err error
+
+	for _, file := range a.files {
+		file.mutex.Lock()
+
+		if file.file != nil {
+			err = errors.Join(err, file.file.Close())
+			file.file = nil
+		}
+
+		file.failed = true
+		file.mutex.Unlock()
+	}
+
+	return err
+}
+
+func (a *Archive) fileOf(meta output.Meta) *archiveFile {
+	context := meta.Context
+	if len(context) == 0 {
+		context = defaultArchiveContext
+	}
+
+	path := filepath.Join(a.directory, archiveName(context), archiveName(meta.Namespace), archiveName(meta.Pod), archiveName(meta.Container)+".log")
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	file, ok := a.files[path]
+	if !ok {
+		file = &archiveFile{path: path}
+		a.files[path] = file
+	}
+
+	return file
+}
+
+func (a *Archive) write(file *archiveFile, content string) error {
+	if file.file == nil {
+		if err := file.open(os.O_APPEND); err != nil {
+			return err
+		}
+	}
+
+	if a.maxSize > 0 && file.size > 0 && file.size+int64(len(content)) > a.maxSize {
+		if err := a.rotate(file); err != nil {
+			return err
+		}
+	}
+
+	written, err := file.file.WriteString(content)
+	file.size += int64(written)
+
+	return err
+}
+
+// rotate shifts the backups, `container.log` becoming `container.log.1`, and starts an empty file
+func (a *Archive) rotate(file *archiveFile) error {
+	if err := file.file.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	file.file = nil
+
+	if a.backups == 0 {
+		return file.open(os.O_TRUNC)
+	}
+
+	for index := a.backups - 1; index > 0; index-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", file.path, index), fmt.Sprintf("%s.%d", file.path, index+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("rotate: %w", err)
+		}
+	}
+
+	if err := os.Rename(file.path, file.path+".1"); err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+
+	return file.open(os.O_TRUNC)
+}
+
+func (af *archiveFile) open(flag int) error {
+	if err := os.MkdirAll(filepath.Dir(af.path), 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(af.path, os.O_CREATE|os.O_WRONLY|flag, 0o644)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(fmt.Errorf("stat: %w", err), file.Close())
+	}
+
+	af.file = file
+	af.size = info.Size()
+
+	return nil
+}
+
+// archiveName makes a name safe for a path, e.g. an EKS context containing a slash
+func archiveName(name string) string {
+	if len(name) == 0 {
+		return "_"
+	}
+
+	return strings.Map(func(char rune) rune {
+		if char == '/' || char == os.PathSeparator {
+			return '_'
+		}
+
+		return char
+	}, name)
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+)
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		meta    output.Meta
+		lines   []string
+		maxSize int64
+		backups int
+	}
+
+	cases := map[string]struct {
+		args args
+		want map[string]string
+		path string
+	}{
+		"simple": {
+			args{
+				meta:  output.Meta{Context: "production", Namespace: "default", Pod: "api-1", Container: "api"},
+				lines: []string{"first", "second"},
+			},
+			map[string]string{
+				"api.log": "first\nsecond\n",
+			},
+			"production/default/api-1",
+		},
+		"sanitized context": {
+			args{
+				meta:  output.Meta{Context: "arn:aws:eks:eu-west-1:1234:cluster/production", Namespace: "default", Pod: "api-1", Container: "api"},
+				lines: []string{"first"},
+			},
+			map[string]string{
+				"api.log": "first\n",
+			},
+			"arn:aws:eks:eu-west-1:1234:cluster_production/default/api-1",
+		},
+		"rotation": {
+			args{
+				meta:    output.Meta{Namespace: "default", Pod: "api-1", Container: "api"},
+				lines:   []string{"first", "second", "third", "fourth", "fifth"},
+				maxSize: 13,
+				backups: 1,
+			},
+			map[string]string{
+				"api.log":   "fifth\n",
+				"api.log.1": "third\nfourth\n",
+			},
+			"default/default/api-1",
+		},
+		"rotation without backup": {
+			args{
+				meta:    output.Meta{Namespace: "default", Pod: "api-1", Container: "api"},
+				lines:   []string{"first", "second", "third"},
+				maxSize: 13,
+			},
+			map[string]string{
+				"api.log": "third\n",
+			},
+			"default/default/api-1",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			directory := t.TempDir()
+
+			archive, err := NewArchive(directory, testCase.args.maxSize, testCase.args.backups)
+			if err != nil {
+				t.Fatalf("NewArchive() = %s", err)
+			}
+
+			for _, line := range testCase.args.lines {
+				if err := archive.Write(testCase.args.meta, line); err != nil {
+					t.Fatalf("Write() = %s", err)
+				}
+			}
+
+			if err := archive.Close(); err != nil {
+				t.Fatalf("Close() = %s", err)
+			}
+
+			entries, err := os.ReadDir(filepath.Join(directory, testCase.path))
+			if err != nil {
+				t.Fatalf("read directory: %s", err)
+			}
+
+			got := make(map[string]string, len(entries))
+
+			for _, entry := range entries {
+				content, err := os.ReadFile(filepath.Join(directory, testCase.path, entry.Name()))
+				if err != nil {
+					t.Fatalf("read file: %s", err)
+				}
+
+				got[entry.Name()] = string(content)
+			}
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Archive = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/ViBiOh/kmux/pkg/output"
@@ -15,6 +15,7 @@ import (
 var colorNames = map[string]*color.Color{
 	"red":    output.Red,
 	"yellow": output.Yellow,
+	"white":  output.White,
 	"green":  output.Green,
 }
 
@@ -48,22 +49,112 @@ func colorIsGreater(first, second *color.Color) bool {
 	return colorRanks[first] > colorRanks[second]
 }
 
+// ColorOf finds the color of a JSON or logfmt log, from the first of the keys found. Keys can be dotted paths of nested objects.
+func ColorOf(content string, keys ...string) *color.Color {
+	value, ok := fieldValue(content, keys...)
+	if !ok {
+		return output.White
+	}
+
+	return colorOfValue(value)
+}
+
 func ColorOfJSON(content string, keys ...string) *color.Color {
-	if !strings.HasPrefix(content, "{") || len(keys) == 0 {
+	value, ok := jsonValue(content, keys...)
+	if !ok {
 		return output.White
 	}
 
+	return colorOfValue(value)
+}
+
+// fieldValue finds the value of the first of the keys found in a JSON or logfmt log
+func fieldValue(content string, keys ...string) (any, bool) {
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	if strings.HasPrefix(content, "{") {
+		return jsonValue(content, keys...)
+	}
+
+	return logfmtValue(content, keys...)
+}
+
+func jsonValue(content string, keys ...string) (any, bool) {
+	if !strings.HasPrefix(content, "{") || len(keys) == 0 {
+		return nil, false
+	}
+
 	decoder := json.NewDecoder(strings.NewReader(content))
 
-	if err := moveDecoderToKey(decoder, keys...); err != nil {
-		return output.White
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
 	}
 
-	token, err := decoder.Token()
-	if err != nil {
-		return output.White
+	value, err := findInObject(decoder, "", keys)
+
+	return value, err == nil
+}
+
+// logfmtValue reads `key=value` pairs, values being optionally quoted, numbers are parsed
+func logfmtValue(content string, keys ...string) (any, bool) {
+	for len(content) != 0 {
+		content = strings.TrimLeft(content, " \t")
+
+		key, rest, found := strings.Cut(content, "=")
+		if !found {
+			return nil, false
+		}
+
+		if strings.ContainsAny(key, " \t") {
+			// not a key, skip the word
+			_, content, _ = strings.Cut(content, " ")
+
+			continue
+		}
+
+		var value string
+		value, content = readLogfmtValue(rest)
+
+		if !matchKey(key, keys) {
+			continue
+		}
+
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number, true
+		}
+
+		return value, true
 	}
 
+	return nil, false
+}
+
+func readLogfmtValue(content string) (string, string) {
+	if strings.HasPrefix(content, `"`) {
+		for index := 1; index < len(content); index++ {
+			switch content[index] {
+			case '\\':
+				index++
+			case '"':
+				if value, err := strconv.Unquote(content[:index+1]); err == nil {
+					return value, content[index+1:]
+				}
+
+				return content[1:index], content[index+1:]
+			}
+		}
+
+		return content[1:], ""
+	}
+
+	value, rest, _ := strings.Cut(content, " ")
+
+	return value, rest
+}
+
+func colorOfValue(token any) *color.Color {
 	switch value := token.(type) {
 	case string:
 		switch strings.ToLower(value) {
@@ -94,35 +185,102 @@ func ColorOfJSON(content string, keys ...string) *color.Color {
 	}
 }
 
-func moveDecoderToKey(decoder *json.Decoder, keys ...string) error {
-	var nested uint64
-
-	for {
+// findInObject walks the object the decoder is in, returning the first scalar value whose dotted path matches one of the keys
+func findInObject(decoder *json.Decoder, path string, keys []string) (any, error) {
+	for decoder.More() {
 		token, err := decoder.Token()
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				return errKeyNotFound
-			}
+			return nil, fmt.Errorf("decode key: %w", err)
+		}
 
-			return fmt.Errorf("decode token: %w", err)
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected key `%v`", token)
 		}
 
-		switch t := token.(type) {
-		case json.Delim:
-			switch t {
-			case '{':
-				nested++
-			case '}':
-				nested--
-			}
-		case string:
-			if nested == 1 {
-				for _, key := range keys {
-					if strings.EqualFold(t, key) {
-						return nil
-					}
+		if len(path) != 0 {
+			key = path + "." + key
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("decode value: %w", err)
+		}
+
+		switch token {
+		case json.Delim('{'):
+			if !matchPrefix(key, keys) {
+				if err := skipValue(decoder); err != nil {
+					return nil, err
 				}
+
+				continue
+			}
+
+			value, err := findInObject(decoder, key, keys)
+			if err == nil {
+				return value, nil
+			}
+
+			if !errors.Is(err, errKeyNotFound) {
+				return nil, err
+			}
+
+		case json.Delim('['):
+			if err := skipValue(decoder); err != nil {
+				return nil, err
+			}
+
+		default:
+			if matchKey(key, keys) {
+				return token, nil
 			}
 		}
 	}
+
+	// consume the closing delimiter
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("decode end of object: %w", err)
+	}
+
+	return nil, errKeyNotFound
+}
+
+// skipValue consumes the object or array the decoder just entered
+func skipValue(decoder *json.Decoder) error {
+	for nested := 1; nested > 0; {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("skip value: %w", err)
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			nested++
+		case json.Delim('}'), json.Delim(']'):
+			nested--
+		}
+	}
+
+	return nil
+}
+
+func matchKey(key string, keys []string) bool {
+	for _, candidate := range keys {
+		if strings.EqualFold(key, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchPrefix(path string, keys []string) bool {
+	for _, candidate := range keys {
+		if len(candidate) > len(path) && candidate[len(path)] == '.' && strings.EqualFold(candidate[:len(path)], path) {
+			return true
+		}
+	}
+
+	return false
 }
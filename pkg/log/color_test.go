@@ -0,0 +1,88 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+	"github.com/fatih/color"
+)
+
+func TestColorOf(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{"level", "log.level", "status", "http.response.status_code"}
+
+	type args struct {
+		content string
+	}
+
+	cases := map[string]struct {
+		args args
+		want *color.Color
+	}{
+		"flat": {
+			args{
+				content: `{"level":"warn","msg":"slow"}`,
+			},
+			output.Yellow,
+		},
+		"nested level": {
+			args{
+				content: `{"log":{"logger":"api","level":"error"},"msg":"boom"}`,
+			},
+			output.Red,
+		},
+		"nested status": {
+			args{
+				content: `{"tags":["a","b"],"http":{"request":{"method":"GET"},"response":{"status_code":503}}}`,
+			},
+			output.Red,
+		},
+		"dotted key": {
+			args{
+				content: `{"log.level":"debug"}`,
+			},
+			output.Green,
+		},
+		"first match": {
+			args{
+				content: `{"status":404,"level":"error"}`,
+			},
+			output.Yellow,
+		},
+		"not found": {
+			args{
+				content: `{"log":{"message":"level"}}`,
+			},
+			output.White,
+		},
+		"logfmt": {
+			args{
+				content: `time=2024-01-01T10:00:00Z msg="request failed" level=error`,
+			},
+			output.Red,
+		},
+		"logfmt status": {
+			args{
+				content: `GET /api status=302 duration=12ms`,
+			},
+			output.Green,
+		},
+		"text": {
+			args{
+				content: "Starting server on :8080",
+			},
+			output.White,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ColorOf(testCase.args.content, keys...); got != testCase.want {
+				t.Errorf("ColorOf() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
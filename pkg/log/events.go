@@ -0,0 +1,244 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/client"
+	"github.com/ViBiOh/kmux/pkg/output"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+var podEventsSelector = fields.OneTermEqualSelector("involvedObject.kind", "Pod").String()
+
+const eventsRewatchDelay = time.Second
+
+// eventsWatcher prints the events involving the pods selected by the logger, inline with their logs.
+// The past events of a pod are listed once it is selected, and new ones are watched in its namespace.
+type eventsWatcher struct {
+	since      time.Time
+	kube       client.Kube
+	pods       map[types.UID]v1.Pod
+	namespaces map[string]bool
+	printed    map[types.UID]printedEvent
+	logger     Logger
+	running    sync.WaitGroup
+	mutex      sync.Mutex
+}
+
+// printedEvent is the version of an event already printed, forgotten with its pod
+type printedEvent struct {
+	resourceVersion string
+	pod             types.UID
+}
+
+func (l Logger) newEventsWatcher(kube client.Kube) *eventsWatcher {
+	since := l.sinceTime
+	if since.IsZero() {
+		since = time.Now().Add(-time.Duration(l.since) * time.Second)
+	}
+
+	return &eventsWatcher{
+		since:      since,
+		kube:       kube,
+		logger:     l,
+		pods:       make(map[types.UID]v1.Pod),
+		namespaces: make(map[string]bool),
+		printed:    make(map[types.UID]printedEvent),
+	}
+}
+
+// add selects the pod, printing its past events and watching the new ones of its namespace when following
+func (ew *eventsWatcher) add(ctx context.Context, pod v1.Pod) {
+	ew.mutex.Lock()
+
+	if _, ok := ew.pods[pod.UID]; ok {
+		ew.mutex.Unlock()
+		return
+	}
+
+	ew.pods[pod.UID] = pod
+
+	watchNamespace := !ew.logger.noFollow && !ew.namespaces[pod.Namespace]
+	ew.namespaces[pod.Namespace] = true
+
+	ew.mutex.Unlock()
+
+	if watchNamespace {
+		ew.running.Go(func() {
+			ew.watch(ctx, pod.Namespace)
+		})
+	}
+
+	ew.running.Go(func() {
+		ew.list(ctx, pod)
+	})
+}
+
+// remove forgets the deleted pod and the events printed for it
+func (ew *eventsWatcher) remove(pod v1.Pod) {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+
+	delete(ew.pods, pod.UID)
+
+	for uid, printed := range ew.printed {
+		if printed.pod == pod.UID {
+			delete(ew.printed, uid)
+		}
+	}
+}
+
+// Wait waits for the watches to end, once the context is done
+func (ew *eventsWatcher) Wait() {
+	ew.running.Wait()
+}
+
+func (ew *eventsWatcher) list(ctx context.Context, pod v1.Pod) {
+	events, err := ew.kube.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.uid", string(pod.UID)).String(),
+	})
+	if err != nil {
+		ew.kube.Err("list events of `%s`: %s", pod.Name, err)
+		return
+	}
+
+	items := events.Items
+	slices.SortFunc(items, func(a, b v1.Event) int {
+		return eventTime(a).Compare(eventTime(b))
+	})
+
+	for _, event := range items {
+		ew.print(event)
+	}
+}
+
+// watch watches the events of the namespace until the context is done, restarting the watch when it fails
+func (ew *eventsWatcher) watch(ctx context.Context, namespace string) {
+	for ew.watchOnce(ctx, namespace) {
+		ew.kube.Warn("events watch of `%s` ended, restarting in %s", namespace, eventsRewatchDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRewatchDelay):
+		}
+	}
+}
+
+// watchOnce prints the events of the namespace from now, and returns true when the watch has to be restarted
+func (ew *eventsWatcher) watchOnce(ctx context.Context, namespace string) bool {
+	events := ew.kube.CoreV1().Events(namespace)
+
+	// the resource version of a minimal list starts the watch from now, the past events being listed per pod
+	current, err := events.List(ctx, metav1.ListOptions{FieldSelector: podEventsSelector, Limit: 1})
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		ew.kube.Err("list events: %s", err)
+		return true
+	}
+
+	watcher, err := watchtools.NewRetryWatcherWithContext(ctx, current.ResourceVersion, &cache.ListWatch{
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = podEventsSelector
+
+			return events.Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		ew.kube.Err("watch events: %s", err)
+		return false
+	}
+
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return ctx.Err() == nil
+			}
+
+			if event.Type == watch.Error {
+				ew.kube.Warn("watch events: %s", apierrors.FromObject(event.Object))
+				return true
+			}
+
+			if item, ok := event.Object.(*v1.Event); ok && event.Type != watch.Deleted {
+				ew.print(*item)
+			}
+		}
+	}
+}
+
+// print prints the event when it involves a selected pod, occurred during the logs and has not been printed yet in this version
+func (ew *eventsWatcher) print(event v1.Event) {
+	timestamp := eventTime(event)
+	if timestamp.Before(ew.since) || !ew.logger.untilTime.IsZero() && timestamp.After(ew.logger.untilTime) {
+		return
+	}
+
+	ew.mutex.Lock()
+
+	pod, ok := ew.pods[event.InvolvedObject.UID]
+	if !ok || ew.printed[event.UID].resourceVersion == event.ResourceVersion {
+		ew.mutex.Unlock()
+		return
+	}
+
+	ew.printed[event.UID] = printedEvent{resourceVersion: event.ResourceVersion, pod: pod.UID}
+
+	ew.mutex.Unlock()
+
+	outputter := ew.kube.Child(ew.logger.rawOutput, output.Cyan.Sprintf("[%s/event]", pod.Name)).WithMeta(output.Meta{Namespace: pod.Namespace, Pod: pod.Name})
+
+	text := fmt.Sprintf("%s %s: %s", event.Type, event.Reason, strings.TrimSpace(event.Message))
+	if event.Count > 1 {
+		text += fmt.Sprintf(" (x%d)", event.Count)
+	}
+
+	eventColor := output.Cyan
+	if event.Type == v1.EventTypeWarning {
+		eventColor = output.Magenta
+	}
+
+	ew.logger.emitRecord(outputter, timestamp, map[string]any{
+		"event": map[string]any{
+			"type":    event.Type,
+			"reason":  event.Reason,
+			"message": event.Message,
+			"count":   event.Count,
+		},
+	}, eventColor.Sprint(text))
+}
+
+// eventTime returns the last time the event occurred, from the first field set
+func eventTime(event v1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
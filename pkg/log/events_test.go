@@ -0,0 +1,166 @@
+package log
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/client/clienttest"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestEventsWatcher(t *testing.T) {
+	t.Parallel()
+
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-1", UID: "api-1"}}
+
+	event := func(name string, involved types.UID, age time.Duration) *v1.Event {
+		return &v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name), ResourceVersion: "1"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: string(involved), UID: involved},
+			Type:           v1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-age)),
+		}
+	}
+
+	type args struct {
+		events []*v1.Event
+		remove bool
+	}
+
+	cases := map[string]struct {
+		args args
+		want []types.UID
+	}{
+		"none": {
+			args{},
+			nil,
+		},
+		"recent": {
+			args{
+				events: []*v1.Event{event("oom", "api-1", time.Minute), event("probe", "api-1", 2*time.Minute)},
+			},
+			[]types.UID{"oom", "probe"},
+		},
+		"other pod": {
+			args{
+				events: []*v1.Event{event("oom", "api-2", time.Minute)},
+			},
+			nil,
+		},
+		"removed pod": {
+			args{
+				events: []*v1.Event{event("oom", "api-1", time.Minute)},
+				remove: true,
+			},
+			nil,
+		},
+		"too old": {
+			args{
+				events: []*v1.Event{event("oom", "api-1", 2*time.Hour), event("probe", "api-1", time.Minute)},
+			},
+			[]types.UID{"probe"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			kube := clienttest.New("prod", "default")
+
+			for _, item := range testCase.args.events {
+				if _, err := kube.CoreV1().Events("default").Create(context.Background(), item, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("create event: %s", err)
+				}
+			}
+
+			events := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithNoFollow(true).newEventsWatcher(kube)
+
+			events.add(context.Background(), pod)
+			events.add(context.Background(), pod)
+			events.Wait()
+
+			if testCase.args.remove {
+				events.remove(pod)
+			}
+
+			var got []types.UID
+			for uid := range events.printed {
+				got = append(got, uid)
+			}
+
+			slices.Sort(got)
+
+			if !slices.Equal(got, testCase.want) {
+				t.Errorf("printed = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestEventsWatcherRestart(t *testing.T) {
+	t.Parallel()
+
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-1", UID: "api-1"}}
+
+	kube := clienttest.New("prod", "default")
+
+	var calls atomic.Int32
+	clienttest.Fake(kube).PrependWatchReactor("events", func(_ k8stesting.Action) (bool, watch.Interface, error) {
+		watcher := watch.NewFakeWithChanSize(1, false)
+
+		if calls.Add(1) == 1 {
+			status := apierrors.NewResourceExpired("too old resource version").ErrStatus
+			watcher.Error(&status)
+
+			return true, watcher, nil
+		}
+
+		watcher.Add(&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "oom", UID: "oom", ResourceVersion: "2"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "api-1", UID: "api-1"},
+			Type:           v1.EventTypeWarning,
+			Reason:         "OOMKilled",
+			LastTimestamp:  metav1.NewTime(time.Now()),
+		})
+
+		return true, watcher, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := NewLogger("", "", "", time.Hour).WithRawOutput(true).newEventsWatcher(kube)
+	events.add(ctx, pod)
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		events.mutex.Lock()
+		_, printed := events.printed["oom"]
+		events.mutex.Unlock()
+
+		if printed {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("event not printed after %d watches", calls.Load())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	events.Wait()
+}
@@ -15,7 +15,17 @@ func Format(text string, outputter *color.Color) string {
 		return text
 	}
 
-	return outputter.Sprint(text)
+	if !strings.Contains(text, "\n") {
+		return outputter.Sprint(text)
+	}
+
+	// each line is printed with its own prefix, so coloring must not span lines
+	lines := strings.Split(text, "\n")
+	for index, line := range lines {
+		lines[index] = outputter.Sprint(line)
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 func FormatGrep(text string, logFilter *regexp.Regexp, outputter *color.Color) string {
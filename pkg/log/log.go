@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,33 +18,91 @@ import (
 	"github.com/ViBiOh/kmux/pkg/resource"
 	"github.com/fatih/color"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 )
 
+// DefaultMultilinePattern matches indented lines and Java's chained causes, as found in most stack traces
+const DefaultMultilinePattern = `^(\s|Caused by:|\.\.\. \d+ more)`
+
+const multilineFlushDelay = 250 * time.Millisecond
+
+// crashTailLines is the number of lines printed from the terminated instance of a restarted container
+const crashTailLines int64 = 50
+
 type Logger struct {
-	selector        map[string]string
 	logRegexes      []*regexp.Regexp
 	containerRegexp *regexp.Regexp
+	multiline       *regexp.Regexp
 	colorFilter     *color.Color
+	sorter          *Sorter
+	archive         *Archive
+	alerter         *Alerter
+	stats           *Stats
+	limiter         *Limiter
+	positions       *streamPositions
+	query           *Query
+	projection      *Projection
 	kind            string
 	name            string
+	selector        string
 	jsonColorKeys   []string
+	colorRules      []ColorRule
+	sinceTime       time.Time
+	untilTime       time.Time
+	dedupeWindow    time.Duration
 	since           int64
+	tailLines       int64
+	limitBytes      int64
 	rawOutput       bool
 	dryRun          bool
 	invertRegexp    bool
 	noFollow        bool
+	timestamps      bool
+	previous        bool
+	crashLogs       bool
+	podEvents       bool
 }
 
-func NewLogger(kind, name string, selector map[string]string, since time.Duration) Logger {
+func NewLogger(kind, name, selector string, since time.Duration) Logger {
 	return Logger{
-		kind:     kind,
-		name:     name,
-		selector: selector,
-		since:    int64(since.Seconds()),
+		kind:      kind,
+		name:      name,
+		selector:  selector,
+		since:     int64(since.Seconds()),
+		tailLines: -1,
 	}
 }
 
+// WithTailLines limits the logs to the last lines of each container, negative for all
+func (l Logger) WithTailLines(tailLines int64) Logger {
+	l.tailLines = tailLines
+
+	return l
+}
+
+// WithSinceTime starts the logs at the given time instead of the since duration, ignored when zero
+func (l Logger) WithSinceTime(sinceTime time.Time) Logger {
+	l.sinceTime = sinceTime
+
+	return l
+}
+
+// WithUntilTime stops the logs at the given time, filtered client-side from the lines' timestamps, ignored when zero
+func (l Logger) WithUntilTime(untilTime time.Time) Logger {
+	l.untilTime = untilTime
+
+	return l
+}
+
+// WithLimitBytes limits the size of the logs of each container, 0 for no limit
+func (l Logger) WithLimitBytes(limitBytes int64) Logger {
+	l.limitBytes = limitBytes
+
+	return l
+}
+
 func (l Logger) WithDryRun(dryRun bool) Logger {
 	l.dryRun = dryRun
 
@@ -84,6 +145,108 @@ func (l Logger) WithJsonColorKeys(jsonColorKeys []string) Logger {
 	return l
 }
 
+// WithColorRules colors logs with the first matching rule, before the default detection
+func (l Logger) WithColorRules(colorRules []ColorRule) Logger {
+	l.colorRules = colorRules
+
+	return l
+}
+
+// WithProjection reformats JSON logs before printing them
+func (l Logger) WithProjection(projection *Projection) Logger {
+	l.projection = projection
+
+	return l
+}
+
+// WithQuery keeps only the JSON logs matching the query
+func (l Logger) WithQuery(query *Query) Logger {
+	l.query = query
+
+	return l
+}
+
+// WithMultiline groups the lines matching the continuation regexp with the previous one, as a single event
+func (l Logger) WithMultiline(continuation *regexp.Regexp) Logger {
+	l.multiline = continuation
+
+	return l
+}
+
+// WithPrevious prints the logs of the previous instance of containers, instead of the current one
+func (l Logger) WithPrevious(previous bool) Logger {
+	l.previous = previous
+
+	return l
+}
+
+// WithCrashLogs prints the last logs of the terminated instance when a followed container restarts, without the lines already streamed
+func (l Logger) WithCrashLogs(crashLogs bool) Logger {
+	l.crashLogs = crashLogs
+
+	l.positions = nil
+	if crashLogs {
+		l.positions = newStreamPositions()
+	}
+
+	return l
+}
+
+// WithSorter merges the logs of all pods and contexts through the sorter, ordered by timestamp
+func (l Logger) WithSorter(sorter *Sorter) Logger {
+	l.sorter = sorter
+
+	return l
+}
+
+// WithArchive also writes the printed logs, uncolored, to the files of the archive
+func (l Logger) WithArchive(archive *Archive) Logger {
+	l.archive = archive
+
+	return l
+}
+
+// WithAlerter checks every line read against the alerter, whatever the filters
+func (l Logger) WithAlerter(alerter *Alerter) Logger {
+	l.alerter = alerter
+
+	return l
+}
+
+// WithStats counts the lines passing the filters in the stats, instead of printing them
+func (l Logger) WithStats(stats *Stats) Logger {
+	l.stats = stats
+
+	return l
+}
+
+// WithDedupe collapses the consecutive lines of a container repeated during the window, ignoring their numbers and ids
+func (l Logger) WithDedupe(window time.Duration) Logger {
+	l.dedupeWindow = window
+
+	return l
+}
+
+// WithLimiter samples and limits the lines printed for each pod
+func (l Logger) WithLimiter(limiter *Limiter) Logger {
+	l.limiter = limiter
+
+	return l
+}
+
+// WithEvents also prints the Kubernetes events involving the selected pods
+func (l Logger) WithEvents(podEvents bool) Logger {
+	l.podEvents = podEvents
+
+	return l
+}
+
+func (l Logger) WithTimestamps(timestamps bool) Logger {
+	l.timestamps = timestamps
+
+	return l
+}
+
 func (l Logger) WithRawOutput(rawOutput bool) Logger {
 	l.rawOutput = rawOutput
 
@@ -101,12 +264,39 @@ func (l Logger) Log(ctx context.Context, kube client.Kube) error {
 	var activeStreams sync.Map
 	var streaming sync.WaitGroup
 
+	restarts := make(map[types.UID]map[string]int32)
+
+	var events *eventsWatcher
+	eventsCtx, eventsCancel := context.WithCancel(ctx)
+	defer eventsCancel()
+
+	if l.podEvents && !l.dryRun {
+		events = l.newEventsWatcher(kube)
+	}
+
 	for event := range podWatcher.ResultChan() {
 		pod, ok := event.Object.(*v1.Pod)
 		if !ok {
 			continue
 		}
 
+		if events != nil {
+			if event.Type == watch.Deleted {
+				events.remove(*pod)
+			} else if event.Type != watch.Error {
+				events.add(eventsCtx, *pod)
+			}
+		}
+
+		if l.crashLogs {
+			if event.Type == watch.Deleted {
+				delete(restarts, pod.UID)
+				l.positions.forget(l.logOutputter(kube, pod.Namespace, pod.Name, "").Meta())
+			} else {
+				l.checkRestarts(ctx, kube, &streaming, restarts, *pod)
+			}
+		}
+
 		streamCancel, ok := activeStreams.Load(pod.UID)
 
 		if event.Type == watch.Deleted || event.Type == watch.Error || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
@@ -129,6 +319,15 @@ func (l Logger) Log(ctx context.Context, kube client.Kube) error {
 
 	streaming.Wait()
 
+	if events != nil {
+		// when not following, only the past events are listed and they are waited for
+		if !l.noFollow {
+			eventsCancel()
+		}
+
+		events.Wait()
+	}
+
 	return nil
 }
 
@@ -145,6 +344,18 @@ func (l Logger) handlePod(ctx context.Context, kube client.Kube, activeStreams *
 			continue
 		}
 
+		if l.previous {
+			if hasTerminated(pod, container.Name) {
+				streaming.Go(func() {
+					l.logPrevious(ctx, kube, pod, container.Name, nil, nil)
+				})
+			} else {
+				l.logOutputter(kube, pod.Namespace, pod.Name, container.Name).Warn("No previous instance.")
+			}
+
+			continue
+		}
+
 		streaming.Go(func() {
 			if pod.Status.Phase != v1.PodRunning {
 				l.logPod(ctx, kube, pod.Namespace, pod.Name, container.Name)
@@ -160,88 +371,476 @@ func (l Logger) handlePod(ctx context.Context, kube client.Kube, activeStreams *
 	}
 }
 
+// checkRestarts prints the crash logs of containers whose restart count increased since the pod was last seen
+func (l Logger) checkRestarts(ctx context.Context, kube client.Kube, streaming *sync.WaitGroup, restarts map[types.UID]map[string]int32, pod v1.Pod) {
+	previous, known := restarts[pod.UID]
+	current := make(map[string]int32)
+
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		current[status.Name] = status.RestartCount
+
+		if !known || status.RestartCount <= previous[status.Name] {
+			continue
+		}
+
+		if l.containerRegexp != nil && !l.containerRegexp.MatchString(status.Name) {
+			continue
+		}
+
+		streaming.Go(func() {
+			l.logCrash(ctx, kube, pod, status)
+		})
+	}
+
+	restarts[pod.UID] = current
+}
+
+func (l Logger) logCrash(ctx context.Context, kube client.Kube, pod v1.Pod, status v1.ContainerStatus) {
+	record := map[string]any{"event": "restart", "restart_count": status.RestartCount}
+	banner := fmt.Sprintf("Container restarted (#%d)", status.RestartCount)
+
+	if terminated := status.LastTerminationState.Terminated; terminated != nil {
+		record["exit_code"] = terminated.ExitCode
+		record["reason"] = terminated.Reason
+		record["finished_at"] = terminated.FinishedAt.Format(time.RFC3339)
+
+		banner = fmt.Sprintf("Container restarted (#%d), exited with code %d (%s) at %s", status.RestartCount, terminated.ExitCode, terminated.Reason, terminated.FinishedAt.Format(time.RFC3339))
+	}
+
+	outputter := l.logOutputter(kube, pod.Namespace, pod.Name, status.Name)
+	outputter.Record(record, "%s", output.Red.Sprintf("=== %s, last logs of the terminated instance ===", banner))
+
+	// the lines already streamed from the terminated instance are skipped, once its stream has ended
+	position := l.positions.load(ctx, outputter.Meta())
+	tailLines := crashTailLines
+
+	l.logPrevious(ctx, kube, pod, status.Name, &tailLines, &position)
+}
+
+func (l Logger) logPrevious(ctx context.Context, kube client.Kube, pod v1.Pod, container string, tailLines *int64, position *streamPosition) {
+	options := l.logOptions(container)
+	options.Previous = true
+	options.SinceSeconds = nil
+	options.SinceTime = nil
+
+	if tailLines != nil {
+		options.TailLines = tailLines
+	}
+
+	content, err := kube.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).DoRaw(ctx)
+	if err != nil {
+		kube.Err("get previous logs: %s", err)
+		return
+	}
+
+	l.outputLog(bytes.NewReader(content), l.logOutputter(kube, pod.Namespace, pod.Name, container), position)
+}
+
+func hasTerminated(pod v1.Pod, container string) bool {
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if status.Name == container {
+			return status.LastTerminationState.Terminated != nil
+		}
+	}
+
+	return false
+}
+
+func (l Logger) logOptions(container string) *v1.PodLogOptions {
+	options := &v1.PodLogOptions{
+		Container:  container,
+		Timestamps: l.requestTimestamps(),
+	}
+
+	if l.sinceTime.IsZero() {
+		since := l.since
+		options.SinceSeconds = &since
+	} else {
+		sinceTime := metav1.NewTime(l.sinceTime)
+		options.SinceTime = &sinceTime
+	}
+
+	if l.tailLines >= 0 {
+		tailLines := l.tailLines
+		options.TailLines = &tailLines
+	}
+
+	if l.limitBytes > 0 {
+		limitBytes := l.limitBytes
+		options.LimitBytes = &limitBytes
+	}
+
+	return options
+}
+
 func (l Logger) logPod(ctx context.Context, kube client.Kube, namespace, name, container string) {
-	content, err := kube.CoreV1().Pods(namespace).GetLogs(name, &v1.PodLogOptions{
-		SinceSeconds: &l.since,
-		Container:    container,
-	}).DoRaw(ctx)
+	content, err := kube.CoreV1().Pods(namespace).GetLogs(name, l.logOptions(container)).DoRaw(ctx)
 	if err != nil {
 		kube.Err("get logs: %s", err)
 		return
 	}
 
-	l.outputLog(bytes.NewReader(content), l.logOutputter(kube, name, container))
+	l.outputLog(bytes.NewReader(content), l.logOutputter(kube, namespace, name, container), nil)
 }
 
+// streamPod prints the logs of the container, resuming the stream from the last line read when it drops while the container is still running
 func (l Logger) streamPod(ctx context.Context, kube client.Kube, namespace, name, container string) {
-	stream, err := kube.CoreV1().Pods(namespace).GetLogs(name, &v1.PodLogOptions{
-		Follow:       !l.noFollow,
-		SinceSeconds: &l.since,
-		Container:    container,
-	}).Stream(ctx)
+	outputter := l.logOutputter(kube, namespace, name, container)
+
+	var position streamPosition
+
+	stream, err := l.openStream(ctx, kube, namespace, name, container, position)
 	if err != nil {
 		kube.Err("stream logs: %s", err)
 		return
 	}
 
-	defer func() {
+	if !l.rawOutput {
+		outputter.Warn("Log...")
+		defer outputter.Warn("Log ended.")
+	}
+
+	backoff := reconnectMinBackoff
+
+	for {
+		last := position.timestamp
+
+		l.positions.start(outputter.Meta())
+		ongoing := l.printLogs(stream, outputter, &position)
+		l.positions.store(outputter.Meta(), position)
+
 		if closeErr := stream.Close(); closeErr != nil {
 			kube.Err("close stream: %s", closeErr)
 		}
-	}()
 
-	l.outputLog(stream, l.logOutputter(kube, name, container))
+		if !ongoing || l.noFollow {
+			return
+		}
+
+		if position.timestamp.After(last) {
+			backoff = reconnectMinBackoff
+		}
+
+		droppedAt := time.Now()
+
+		if stream = l.reopenStream(ctx, kube, outputter, namespace, name, container, &position, &backoff); stream == nil {
+			return
+		}
+
+		outputter.Warn("Log stream dropped for %s, resumed from %s", time.Since(droppedAt).Round(time.Second), position.timestamp.Format(time.RFC3339Nano))
+	}
 }
 
-func (l Logger) logOutputter(kube client.Kube, name, container string) output.Outputter {
-	return kube.Child(l.rawOutput, output.Green.Sprintf("[%s/%s]", name, container))
+func (l Logger) logOutputter(kube client.Kube, namespace, name, container string) output.Outputter {
+	return kube.Child(l.rawOutput, output.Green.Sprintf("[%s/%s]", name, container)).WithMeta(output.Meta{Namespace: namespace, Pod: name, Container: container})
 }
 
-func (l Logger) outputLog(reader io.Reader, outputter output.Outputter) {
+func (l Logger) outputLog(reader io.Reader, outputter output.Outputter, position *streamPosition) {
 	if !l.rawOutput {
 		outputter.Warn("Log...")
 		defer outputter.Warn("Log ended.")
 	}
 
-	streamScanner := bufio.NewScanner(reader)
-	streamScanner.Split(bufio.ScanLines)
+	l.printLogs(reader, outputter, position)
+}
 
-	var colorOutputter *color.Color
+// printLogs prints the events of the reader, skipping the ones already read from the position when given. It returns false once after the until time.
+func (l Logger) printLogs(reader io.Reader, outputter output.Outputter, position *streamPosition) bool {
+	var dedupe *deduper
+	if l.dedupeWindow > 0 {
+		dedupe = newDeduper(l.dedupeWindow, func(timestamp time.Time, repeated uint) {
+			l.notice(outputter, timestamp, map[string]any{"repeated": repeated}, "Last line repeated %d times", repeated)
+		})
 
-	for streamScanner.Scan() {
-		text := streamScanner.Text()
+		defer dedupe.flush()
+	}
 
-		colorOutputter = ColorOfJSON(text, l.jsonColorKeys...)
+	var last time.Time
 
-		if colorIsGreater(colorOutputter, l.colorFilter) {
+	if l.limiter != nil {
+		defer func() {
+			l.noticeDropped(outputter, last, l.limiter.Flush(outputter.Meta()))
+		}()
+	}
+
+	for event := range l.events(reader) {
+		timestamp, text := event.timestamp, event.text
+		last = timestamp
+
+		// lines of a container are ordered, nothing more to read once after the until time
+		if !l.untilTime.IsZero() && timestamp.After(l.untilTime) {
+			return false
+		}
+
+		if position != nil && position.seen(timestamp) {
 			continue
 		}
 
-		if len(l.logRegexes) == 0 {
-			outputter.Std("%s", Format(text, colorOutputter))
+		if l.alerter != nil {
+			l.alerter.Check(outputter, timestamp, text)
+		}
+
+		colorOutputter, severity := l.colorOf(text)
 
+		if colorIsGreater(severity, l.colorFilter) {
 			continue
 		}
 
-		if !l.grepMatch(text) {
+		if l.query != nil && !l.query.Match(text) {
 			continue
 		}
 
-		greppedText := text
-		for _, logRegexp := range l.logRegexes {
-			greppedText = FormatGrep(greppedText, logRegexp, colorOutputter)
+		if len(l.logRegexes) != 0 && !l.grepMatch(text) {
+			continue
 		}
 
-		outputter.Std("%s", greppedText)
+		// the lines are only counted when summarized, but still archived
+		if l.stats != nil {
+			l.stats.Add(outputter.Meta(), severity, text)
+
+			if l.archive != nil {
+				l.archiveLine(outputter, timestamp, text)
+			}
+
+			continue
+		}
+
+		if !l.throttle(outputter, dedupe, timestamp, text) {
+			continue
+		}
+
+		l.emit(outputter, timestamp, text, l.format(text, colorOutputter))
 	}
+
+	return true
 }
 
-func (l Logger) grepMatch(text string) bool {
+// format projects and colors the text, highlighting the grep matches
+func (l Logger) format(text string, colorOutputter *color.Color) string {
+	displayed := text
+	if l.projection != nil {
+		displayed = l.projection.Format(text)
+	}
+
+	if len(l.logRegexes) == 0 {
+		return Format(displayed, colorOutputter)
+	}
+
 	for _, logRegexp := range l.logRegexes {
-		if logRegexp.MatchString(text) {
-			return !l.invertRegexp
+		displayed = FormatGrep(displayed, logRegexp, colorOutputter)
+	}
+
+	return displayed
+}
+
+// throttle returns false when the line is dropped by the deduplication or the limiter, noticing the lines dropped before it
+func (l Logger) throttle(outputter output.Outputter, dedupe *deduper, timestamp time.Time, text string) bool {
+	if dedupe != nil && dedupe.push(timestamp, text) {
+		return false
+	}
+
+	if l.limiter == nil {
+		return true
+	}
+
+	now := timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	allowed, dropped := l.limiter.Allow(outputter.Meta(), now)
+	l.noticeDropped(outputter, timestamp, dropped)
+
+	return allowed
+}
+
+func (l Logger) noticeDropped(outputter output.Outputter, timestamp time.Time, dropped uint) {
+	if dropped > 0 {
+		l.notice(outputter, timestamp, map[string]any{"dropped": dropped}, "%d lines dropped by rate limit", dropped)
+	}
+}
+
+// notice outputs a notice about the lines of the stream, ordered with them by the sorter and as a record when structured
+func (l Logger) notice(outputter output.Outputter, timestamp time.Time, record map[string]any, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	record["notice"] = message
+
+	l.emitRecord(outputter, timestamp, record, output.Yellow.Sprint(message))
+}
+
+// colorOf returns the color of the text and its severity
+func (l Logger) colorOf(text string) (*color.Color, *color.Color) {
+	if textColor, severity, ok := colorOfRules(l.colorRules, text); ok {
+		return textColor, severity
+	}
+
+	textColor := ColorOf(text, l.jsonColorKeys...)
+
+	return textColor, textColor
+}
+
+type logEvent struct {
+	timestamp time.Time
+	text      string
+}
+
+// events yields the lines of the reader, grouped when multiline is enabled
+func (l Logger) events(reader io.Reader) iter.Seq[logEvent] {
+	return func(yield func(logEvent) bool) {
+		streamScanner := bufio.NewScanner(reader)
+		streamScanner.Split(bufio.ScanLines)
+
+		if l.multiline == nil {
+			for streamScanner.Scan() {
+				if !yield(l.parseLine(streamScanner.Text())) {
+					return
+				}
+			}
+
+			return
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+
+		lines := make(chan logEvent)
+
+		go func() {
+			defer close(lines)
+
+			for streamScanner.Scan() {
+				select {
+				case <-done:
+					return
+				case lines <- l.parseLine(streamScanner.Text()):
+				}
+			}
+		}()
+
+		groupLines(lines, l.multiline, multilineFlushDelay, yield)
+	}
+}
+
+// groupLines appends continuation lines to the pending event, emitted on the next new event or after the delay without line
+func groupLines(lines <-chan logEvent, continuation *regexp.Regexp, delay time.Duration, yield func(logEvent) bool) {
+	var pending logEvent
+	var hasPending bool
+
+	flush := time.NewTimer(delay)
+	defer flush.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if hasPending {
+					yield(pending)
+				}
+
+				return
+			}
+
+			if hasPending && continuation.MatchString(line.text) {
+				pending.text += "\n" + line.text
+			} else {
+				if hasPending && !yield(pending) {
+					return
+				}
+
+				pending, hasPending = line, true
+			}
+
+			flush.Reset(delay)
+
+		case <-flush.C:
+			if hasPending {
+				hasPending = false
+
+				if !yield(pending) {
+					return
+				}
+			}
 		}
 	}
+}
+
+func (l Logger) parseLine(text string) logEvent {
+	if l.requestTimestamps() {
+		timestamp, content := splitTimestamp(text)
+
+		return logEvent{timestamp: timestamp, text: content}
+	}
+
+	return logEvent{text: text}
+}
+
+// requestTimestamps is true when lines' timestamps are needed, followed streams being resumed from the last one read
+func (l Logger) requestTimestamps() bool {
+	return l.timestamps || l.sorter != nil || !l.untilTime.IsZero() || !l.noFollow
+}
+
+func (l Logger) emit(outputter output.Outputter, timestamp time.Time, text, formatted string) {
+	if l.archive != nil {
+		l.archiveLine(outputter, timestamp, text)
+	}
+
+	l.emitRecord(outputter, timestamp, logRecord(text), formatted)
+}
+
+// emitRecord outputs the formatted text, or the record when structured, through the sorter if any
+func (l Logger) emitRecord(outputter output.Outputter, timestamp time.Time, record map[string]any, formatted string) {
+	if l.timestamps && !timestamp.IsZero() {
+		rawTimestamp := timestamp.Format(time.RFC3339Nano)
+
+		record["timestamp"] = rawTimestamp
+		formatted = output.Blue.Sprint(rawTimestamp) + " " + formatted
+	}
+
+	if l.sorter == nil {
+		outputter.Record(record, "%s", formatted)
+		return
+	}
+
+	l.sorter.Push(timestamp, func() {
+		outputter.Record(record, "%s", formatted)
+	})
+}
+
+func (l Logger) archiveLine(outputter output.Outputter, timestamp time.Time, text string) {
+	if l.timestamps && !timestamp.IsZero() {
+		text = timestamp.Format(time.RFC3339Nano) + " " + text
+	}
+
+	if err := l.archive.Write(outputter.Meta(), text); err != nil {
+		outputter.Err("archive log: %s", err)
+	}
+}
+
+// splitTimestamp extracts the RFC3339 timestamp prefixed by the API server on each line
+func splitTimestamp(text string) (time.Time, string) {
+	rawTimestamp, content, _ := strings.Cut(text, " ")
+
+	timestamp, err := time.Parse(time.RFC3339Nano, rawTimestamp)
+	if err != nil {
+		return time.Time{}, text
+	}
+
+	return timestamp, content
+}
+
+// logRecord embeds JSON logs as is, raw text otherwise
+func logRecord(text string) map[string]any {
+	if strings.HasPrefix(text, "{") && json.Valid([]byte(text)) {
+		return map[string]any{"log": json.RawMessage(text)}
+	}
+
+	return map[string]any{"message": text}
+}
+
+func (l Logger) grepMatch(text string) bool {
+	if matchAny(l.logRegexes, text) != nil {
+		return !l.invertRegexp
+	}
 
 	return l.invertRegexp
 }
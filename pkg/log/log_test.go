@@ -0,0 +1,590 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/client/clienttest"
+	"github.com/ViBiOh/kmux/pkg/output"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestLogRecord(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		text string
+	}
+
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"text": {
+			args{
+				text: "Starting server on :8080",
+			},
+			`{"message":"Starting server on :8080"}`,
+		},
+		"json": {
+			args{
+				text: `{"level":"error","msg":"connection refused"}`,
+			},
+			`{"log":{"level":"error","msg":"connection refused"}}`,
+		},
+		"invalid json": {
+			args{
+				text: `{"level":"error"`,
+			},
+			`{"message":"{\"level\":\"error\""}`,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			payload, err := json.Marshal(logRecord(testCase.args.text))
+			if err != nil {
+				t.Fatalf("marshal: %s", err)
+			}
+
+			if got := string(payload); got != testCase.want {
+				t.Errorf("logRecord() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestSplitTimestamp(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		text string
+	}
+
+	cases := map[string]struct {
+		args        args
+		want        string
+		wantContent string
+	}{
+		"timestamp": {
+			args{
+				text: "2024-01-01T10:00:00.123456789Z Starting server",
+			},
+			"2024-01-01T10:00:00.123456789Z",
+			"Starting server",
+		},
+		"no timestamp": {
+			args{
+				text: "Starting server",
+			},
+			"0001-01-01T00:00:00Z",
+			"Starting server",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotContent := splitTimestamp(testCase.args.text)
+			if got.Format(time.RFC3339Nano) != testCase.want || gotContent != testCase.wantContent {
+				t.Errorf("splitTimestamp() = (%s, `%s`), want (%s, `%s`)", got.Format(time.RFC3339Nano), gotContent, testCase.want, testCase.wantContent)
+			}
+		})
+	}
+}
+
+func TestLogOptions(t *testing.T) {
+	t.Parallel()
+
+	since := int64(3600)
+	tailLines := int64(10)
+	limitBytes := int64(1024)
+	sinceTime := metav1.NewTime(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	cases := map[string]struct {
+		instance Logger
+		want     *v1.PodLogOptions
+	}{
+		"default": {
+			NewLogger("deployment", "api", "", time.Hour),
+			&v1.PodLogOptions{
+				Container:    "api",
+				SinceSeconds: &since,
+				Timestamps:   true,
+			},
+		},
+		"bounded": {
+			NewLogger("deployment", "api", "", time.Hour).WithNoFollow(true).WithTailLines(10).WithLimitBytes(1024),
+			&v1.PodLogOptions{
+				Container:    "api",
+				SinceSeconds: &since,
+				TailLines:    &tailLines,
+				LimitBytes:   &limitBytes,
+			},
+		},
+		"time window": {
+			NewLogger("deployment", "api", "", time.Hour).WithSinceTime(sinceTime.Time).WithUntilTime(sinceTime.Add(time.Hour)),
+			&v1.PodLogOptions{
+				Container:  "api",
+				SinceTime:  &sinceTime,
+				Timestamps: true,
+			},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.instance.logOptions("api"); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("logOptions() = %+v, want %+v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestGroupLines(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		lines []string
+	}
+
+	cases := map[string]struct {
+		args args
+		want []string
+	}{
+		"single lines": {
+			args{
+				lines: []string{"first", "second"},
+			},
+			[]string{"first", "second"},
+		},
+		"java stack trace": {
+			args{
+				lines: []string{
+					"Exception in thread \"main\" java.lang.IllegalStateException: boom",
+					"\tat com.example.App.main(App.java:12)",
+					"Caused by: java.lang.NullPointerException",
+					"\t... 1 more",
+					"Next event",
+				},
+			},
+			[]string{
+				"Exception in thread \"main\" java.lang.IllegalStateException: boom\n\tat com.example.App.main(App.java:12)\nCaused by: java.lang.NullPointerException\n\t... 1 more",
+				"Next event",
+			},
+		},
+		"leading continuation": {
+			args{
+				lines: []string{"  orphan", "event"},
+			},
+			[]string{"  orphan", "event"},
+		},
+	}
+
+	continuation := regexp.MustCompile(DefaultMultilinePattern)
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			lines := make(chan logEvent, len(testCase.args.lines))
+			for _, line := range testCase.args.lines {
+				lines <- logEvent{text: line}
+			}
+			close(lines)
+
+			var got []string
+			groupLines(lines, continuation, time.Hour, func(event logEvent) bool {
+				got = append(got, event.text)
+				return true
+			})
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("groupLines() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestCheckRestarts(t *testing.T) {
+	t.Parallel()
+
+	pod := func(restartCounts ...int32) v1.Pod {
+		output := v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-1", UID: "api-1"}}
+
+		for index, restartCount := range restartCounts {
+			output.Status.ContainerStatuses = append(output.Status.ContainerStatuses, v1.ContainerStatus{
+				Name:         fmt.Sprintf("container-%d", index),
+				RestartCount: restartCount,
+				LastTerminationState: v1.ContainerState{
+					Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"},
+				},
+			})
+		}
+
+		return output
+	}
+
+	type args struct {
+		pods []v1.Pod
+	}
+
+	cases := map[string]struct {
+		args args
+		want []string
+	}{
+		"initial restarts": {
+			args{
+				pods: []v1.Pod{pod(3, 0)},
+			},
+			nil,
+		},
+		"restart": {
+			args{
+				pods: []v1.Pod{pod(0, 0), pod(0, 0), pod(0, 1)},
+			},
+			[]string{"container-1"},
+		},
+		"multiple restarts": {
+			args{
+				pods: []v1.Pod{pod(0, 0), pod(1, 0), pod(1, 2)},
+			},
+			[]string{"container-0", "container-1"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			kube := clienttest.New("prod", "default")
+			logger := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithCrashLogs(true)
+
+			var streaming sync.WaitGroup
+			restarts := make(map[types.UID]map[string]int32)
+
+			for _, item := range testCase.args.pods {
+				logger.checkRestarts(context.Background(), kube, &streaming, restarts, item)
+			}
+
+			streaming.Wait()
+
+			var got []string
+			for _, action := range clienttest.Fake(kube).Actions() {
+				if action.GetSubresource() != "log" {
+					continue
+				}
+
+				options := action.(k8stesting.GenericAction).GetValue().(*v1.PodLogOptions)
+				if options.Previous {
+					got = append(got, options.Container)
+				}
+			}
+
+			slices.Sort(got)
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("checkRestarts() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+// logLines formats the lines as served by the API server with timestamps, one millisecond apart from the start
+func logLines(start time.Time, lines ...string) string {
+	var builder strings.Builder
+
+	for index, line := range lines {
+		fmt.Fprintf(&builder, "%s %s\n", start.Add(time.Duration(index)*time.Millisecond).Format(time.RFC3339Nano), line)
+	}
+
+	return builder.String()
+}
+
+// archivedLines closes the archive and returns the lines printed for the container of the outputter
+func archivedLines(t *testing.T, archive *Archive, directory string, outputter output.Outputter) []string {
+	t.Helper()
+
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close archive: %s", err)
+	}
+
+	meta := outputter.Meta()
+
+	content, err := os.ReadFile(filepath.Join(directory, meta.Context, meta.Namespace, meta.Pod, meta.Container+".log"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		t.Fatalf("read archive: %s", err)
+	}
+
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func TestCrashLogsPosition(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	type args struct {
+		streamed string
+	}
+
+	cases := map[string]struct {
+		args args
+		want []string
+	}{
+		"not streamed": {
+			args{},
+			[]string{"Starting", "Connecting", "panic: connection refused"},
+		},
+		"streamed": {
+			args{
+				streamed: logLines(start, "Starting", "Connecting"),
+			},
+			[]string{"Starting", "Connecting", "panic: connection refused"},
+		},
+		"partially streamed": {
+			args{
+				streamed: logLines(start, "Starting"),
+			},
+			[]string{"Starting", "Connecting", "panic: connection refused"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			directory := t.TempDir()
+
+			archive, err := NewArchive(directory, 0, 0)
+			if err != nil {
+				t.Fatalf("NewArchive() = %s", err)
+			}
+
+			logger := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithCrashLogs(true).WithArchive(archive)
+			outputter := output.NewOutputter("prod").WithMeta(output.Meta{Namespace: "default", Pod: "api-1", Container: "api"})
+
+			if len(testCase.args.streamed) != 0 {
+				var position streamPosition
+
+				logger.printLogs(strings.NewReader(testCase.args.streamed), outputter, &position)
+				logger.positions.store(outputter.Meta(), position)
+			}
+
+			previous := logger.positions.load(context.Background(), outputter.Meta())
+			logger.printLogs(strings.NewReader(logLines(start, "Starting", "Connecting", "panic: connection refused")), outputter, &previous)
+
+			if got := archivedLines(t, archive, directory, outputter); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("printLogs() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestCrashLogsWhileStreaming(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	directory := t.TempDir()
+
+	archive, err := NewArchive(directory, 0, 0)
+	if err != nil {
+		t.Fatalf("NewArchive() = %s", err)
+	}
+
+	logger := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithCrashLogs(true).WithArchive(archive)
+	outputter := output.NewOutputter("prod").WithMeta(output.Meta{Namespace: "default", Pod: "api-1", Container: "api"})
+
+	reader, writer := io.Pipe()
+
+	logger.positions.start(outputter.Meta())
+
+	go func() {
+		var position streamPosition
+
+		logger.printLogs(reader, outputter, &position)
+		logger.positions.store(outputter.Meta(), position)
+	}()
+
+	first, second, _ := strings.Cut(logLines(start, "Starting", "Connecting"), "\n")
+
+	if _, err := io.WriteString(writer, first+"\n"); err != nil {
+		t.Fatalf("write stream: %s", err)
+	}
+
+	loaded := make(chan streamPosition, 1)
+
+	go func() {
+		loaded <- logger.positions.load(context.Background(), outputter.Meta())
+	}()
+
+	// the terminated instance is still being drained by the stream
+	select {
+	case <-loaded:
+		t.Fatal("load() returned while the stream is open")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, err := io.WriteString(writer, second); err != nil {
+		t.Fatalf("write stream: %s", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close stream: %s", err)
+	}
+
+	previous := <-loaded
+	logger.printLogs(strings.NewReader(logLines(start, "Starting", "Connecting", "panic: connection refused")), outputter, &previous)
+
+	if got, want := archivedLines(t, archive, directory, outputter), []string{"Starting", "Connecting", "panic: connection refused"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("printLogs() = %q, want %q", got, want)
+	}
+}
+
+func TestStatsArchive(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	archive, err := NewArchive(directory, 0, 0)
+	if err != nil {
+		t.Fatalf("NewArchive() = %s", err)
+	}
+
+	stats := NewStats(0, 2)
+
+	logger := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithStats(stats).WithArchive(archive)
+	outputter := output.NewOutputter("prod").WithMeta(output.Meta{Namespace: "default", Pod: "api-1", Container: "api"})
+
+	logger.printLogs(strings.NewReader("Starting\nListening on :8080\n"), outputter, nil)
+
+	want := []string{"Starting", "Listening on :8080"}
+	if got := archivedLines(t, archive, directory, outputter); !reflect.DeepEqual(got, want) {
+		t.Errorf("printLogs() archived %q, want %q", got, want)
+	}
+
+	if got := stats.pods[podKey{context: "prod", namespace: "default", pod: "api-1"}].lines; got != 2 {
+		t.Errorf("printLogs() counted %d lines, want 2", got)
+	}
+}
+
+func TestPrintLogsUntil(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	type args struct {
+		untilTime time.Time
+	}
+
+	cases := map[string]struct {
+		args        args
+		want        []string
+		wantOngoing bool
+	}{
+		"none": {
+			args{},
+			[]string{"first", "second", "third"},
+			true,
+		},
+		"after the lines": {
+			args{
+				untilTime: start.Add(time.Second),
+			},
+			[]string{"first", "second", "third"},
+			true,
+		},
+		"inclusive": {
+			args{
+				untilTime: start.Add(time.Millisecond),
+			},
+			[]string{"first", "second"},
+			false,
+		},
+		"before the lines": {
+			args{
+				untilTime: start.Add(-time.Second),
+			},
+			nil,
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			directory := t.TempDir()
+
+			archive, err := NewArchive(directory, 0, 0)
+			if err != nil {
+				t.Fatalf("NewArchive() = %s", err)
+			}
+
+			logger := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithUntilTime(testCase.args.untilTime).WithArchive(archive)
+			outputter := output.NewOutputter("prod").WithMeta(output.Meta{Namespace: "default", Pod: "api-1", Container: "api"})
+
+			gotOngoing := logger.printLogs(strings.NewReader(logLines(start, "first", "second", "third")), outputter, &streamPosition{})
+
+			if got := archivedLines(t, archive, directory, outputter); !reflect.DeepEqual(got, testCase.want) || gotOngoing != testCase.wantOngoing {
+				t.Errorf("printLogs() = (%q, %t), want (%q, %t)", got, gotOngoing, testCase.want, testCase.wantOngoing)
+			}
+		})
+	}
+}
+
+func TestStreamPodUntil(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	kube := clienttest.New("prod", "default")
+
+	var streams int
+	clienttest.Fake(kube).PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "log" {
+			return false, nil, nil
+		}
+
+		streams++
+
+		return true, &runtime.Unknown{Raw: []byte(logLines(start, "first", "second", "third"))}, nil
+	})
+
+	logger := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithUntilTime(start.Add(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), reconnectMinBackoff/2)
+	defer cancel()
+
+	logger.streamPod(ctx, kube, "default", "api-1", "api")
+
+	// the followed stream stops at the first line after the until time, without waiting for a reconnection
+	if ctx.Err() != nil || streams != 1 {
+		t.Errorf("streamPod() opened %d streams and ended with `%v`, want 1 stream ended before the context", streams, ctx.Err())
+	}
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Projection reformats JSON logs with a template or a list of fields, other lines are kept as is
+type Projection struct {
+	template *template.Template
+	fields   []string
+}
+
+func NewTemplateProjection(raw string) (*Projection, error) {
+	tmpl, err := template.New("log").Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+
+	return &Projection{template: tmpl}, nil
+}
+
+// NewFieldsProjection prints the values of the fields separated by a space, `-` for missing ones. Fields can be dotted paths of nested objects.
+func NewFieldsProjection(fields []string) *Projection {
+	return &Projection{fields: fields}
+}
+
+func (p *Projection) Format(text string) string {
+	if !strings.HasPrefix(text, "{") {
+		return text
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+
+	var content map[string]any
+	if err := decoder.Decode(&content); err != nil {
+		return text
+	}
+
+	// content after the JSON object, e.g. a grouped stack trace, is kept
+	rest := text[decoder.InputOffset():]
+
+	if p.template != nil {
+		var output strings.Builder
+		if err := p.template.Execute(&output, content); err != nil {
+			return text
+		}
+
+		return output.String() + rest
+	}
+
+	values := make([]string, len(p.fields))
+
+	for index, field := range p.fields {
+		values[index] = projectField(content, field)
+	}
+
+	return strings.Join(values, " ") + rest
+}
+
+func projectField(content map[string]any, field string) string {
+	value, ok := lookupField(content, field)
+	if !ok {
+		return "-"
+	}
+
+	if text, ok := stringOfField(value); ok {
+		return text
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "-"
+	}
+
+	return string(payload)
+}
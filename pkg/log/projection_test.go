@@ -0,0 +1,72 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestProjection(t *testing.T) {
+	t.Parallel()
+
+	content := `{"ts":"2024-01-01T10:00:00Z","level":"error","msg":"request failed","http":{"status":503},"tags":["a"]}`
+
+	templateProjection, err := NewTemplateProjection("[{{.level}}] {{.msg}} ({{.http.status}})")
+	if err != nil {
+		t.Fatalf("NewTemplateProjection() = %s", err)
+	}
+
+	type args struct {
+		projection *Projection
+		text       string
+	}
+
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"fields": {
+			args{
+				projection: NewFieldsProjection([]string{"ts", "level", "msg"}),
+				text:       content,
+			},
+			"2024-01-01T10:00:00Z error request failed",
+		},
+		"nested and missing fields": {
+			args{
+				projection: NewFieldsProjection([]string{"http.status", "tags", "trace_id"}),
+				text:       content,
+			},
+			`503 ["a"] -`,
+		},
+		"template": {
+			args{
+				projection: templateProjection,
+				text:       content,
+			},
+			"[error] request failed (503)",
+		},
+		"stack trace": {
+			args{
+				projection: NewFieldsProjection([]string{"level"}),
+				text:       content + "\n\tat com.example.App.main(App.java:12)",
+			},
+			"error\n\tat com.example.App.main(App.java:12)",
+		},
+		"text": {
+			args{
+				projection: NewFieldsProjection([]string{"level"}),
+				text:       "Starting server on :8080",
+			},
+			"Starting server on :8080",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.args.projection.Format(testCase.args.text); got != testCase.want {
+				t.Errorf("Format() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,566 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind uint8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenIn
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+type token struct {
+	value    string
+	position int
+	kind     tokenKind
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+
+	return fmt.Sprintf("`%s` at position %d", t.value, t.position)
+}
+
+type node interface {
+	eval(map[string]any) bool
+}
+
+// Query filters JSON logs on their fields, e.g. `level in (error,warn) && latency_ms > 500 && path ~ "^/api"`.
+//
+// Keys can be nested with dots, comparisons of strings are case-insensitive, and a comparison on a missing key is false.
+// A key alone checks its presence. Lines that are not JSON objects never match.
+type Query struct {
+	root node
+}
+
+func ParseQuery(expression string) (*Query, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.next(); next.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s", next)
+	}
+
+	return &Query{root: root}, nil
+}
+
+func (q *Query) Match(content string) bool {
+	if !strings.HasPrefix(content, "{") {
+		return false
+	}
+
+	var fields map[string]any
+
+	// Decode only reads the first value, a JSON line followed by a multi-line stack trace is still decoded
+	if err := json.NewDecoder(strings.NewReader(content)).Decode(&fields); err != nil {
+		return false
+	}
+
+	return q.root.eval(fields)
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(expression); {
+		char := expression[i]
+		rest := expression[i:]
+
+		switch {
+		case char == ' ' || char == '\t' || char == '\n':
+			i++
+
+		case char == '(':
+			tokens = append(tokens, token{kind: tokenOpen, value: "(", position: i})
+			i++
+
+		case char == ')':
+			tokens = append(tokens, token{kind: tokenClose, value: ")", position: i})
+			i++
+
+		case char == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", position: i})
+			i++
+
+		case strings.HasPrefix(rest, "&&"):
+			tokens = append(tokens, token{kind: tokenAnd, value: "&&", position: i})
+			i += 2
+
+		case strings.HasPrefix(rest, "||"):
+			tokens = append(tokens, token{kind: tokenOr, value: "||", position: i})
+			i += 2
+
+		case strings.HasPrefix(rest, "=="), strings.HasPrefix(rest, "!="), strings.HasPrefix(rest, ">="), strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, "!~"):
+			tokens = append(tokens, token{kind: tokenOperator, value: rest[:2], position: i})
+			i += 2
+
+		case char == '>' || char == '<' || char == '~':
+			tokens = append(tokens, token{kind: tokenOperator, value: rest[:1], position: i})
+			i++
+
+		case char == '!':
+			tokens = append(tokens, token{kind: tokenNot, value: "!", position: i})
+			i++
+
+		case char == '"' || char == '\'':
+			value, length, err := readQuoted(rest)
+			if err != nil {
+				return nil, fmt.Errorf("%w at position %d", err, i)
+			}
+
+			tokens = append(tokens, token{kind: tokenString, value: value, position: i})
+			i += length
+
+		case isWordChar(char):
+			start := i
+			for i < len(expression) && isWordChar(expression[i]) {
+				i++
+			}
+
+			word := expression[start:i]
+
+			kind := tokenWord
+			switch strings.ToLower(word) {
+			case "and":
+				kind = tokenAnd
+			case "or":
+				kind = tokenOr
+			case "not":
+				kind = tokenNot
+			case "in":
+				kind = tokenIn
+			}
+
+			tokens = append(tokens, token{kind: kind, value: word, position: start})
+
+		default:
+			return nil, fmt.Errorf("unexpected character `%c` at position %d", char, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, position: len(expression)}), nil
+}
+
+// readQuoted reads a string delimited by its first char, only the escaped delimiter is unescaped for keeping regexp as is
+func readQuoted(content string) (string, int, error) {
+	quote := content[0]
+
+	var value strings.Builder
+
+	for i := 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			if i+1 < len(content) && content[i+1] == quote {
+				value.WriteByte(quote)
+				i++
+			} else {
+				value.WriteByte('\\')
+			}
+
+		case quote:
+			return value.String(), i + 1, nil
+
+		default:
+			value.WriteByte(content[i])
+		}
+	}
+
+	return "", 0, errors.New("unterminated string")
+}
+
+func isWordChar(char byte) bool {
+	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || strings.IndexByte("_-.@$/:", char) != -1
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	current := p.tokens[p.position]
+	if current.kind != tokenEOF {
+		p.position++
+	}
+
+	return current
+}
+
+func (p *parser) expect(kind tokenKind, name string) error {
+	if next := p.next(); next.kind != kind {
+		return fmt.Errorf("expected %s, got %s", name, next)
+	}
+
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenAnd {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind != tokenNot {
+		return p.parsePrimary()
+	}
+
+	p.next()
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return notNode{operand: operand}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	current := p.next()
+
+	switch current.kind {
+	case tokenOpen:
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenClose, "`)`"); err != nil {
+			return nil, err
+		}
+
+		return expression, nil
+
+	case tokenWord, tokenString:
+		return p.parseComparison(current.value)
+
+	default:
+		return nil, fmt.Errorf("expected key, got %s", current)
+	}
+}
+
+func (p *parser) parseComparison(key string) (node, error) {
+	switch p.peek().kind {
+	case tokenOperator:
+		operator := p.next().value
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		return newCompareNode(key, operator, value)
+
+	case tokenIn:
+		p.next()
+
+		if err := p.expect(tokenOpen, "`(`"); err != nil {
+			return nil, err
+		}
+
+		var values []string
+
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+
+			if p.peek().kind != tokenComma {
+				break
+			}
+
+			p.next()
+		}
+
+		if err := p.expect(tokenClose, "`)`"); err != nil {
+			return nil, err
+		}
+
+		return inNode{key: key, values: values}, nil
+
+	default:
+		return existsNode{key: key}, nil
+	}
+}
+
+func (p *parser) parseValue() (string, error) {
+	current := p.next()
+	if current.kind != tokenWord && current.kind != tokenString {
+		return "", fmt.Errorf("expected value, got %s", current)
+	}
+
+	return current.value, nil
+}
+
+type andNode struct {
+	left  node
+	right node
+}
+
+func (n andNode) eval(fields map[string]any) bool {
+	return n.left.eval(fields) && n.right.eval(fields)
+}
+
+type orNode struct {
+	left  node
+	right node
+}
+
+func (n orNode) eval(fields map[string]any) bool {
+	return n.left.eval(fields) || n.right.eval(fields)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(fields map[string]any) bool {
+	return !n.operand.eval(fields)
+}
+
+type existsNode struct {
+	key string
+}
+
+func (n existsNode) eval(fields map[string]any) bool {
+	_, ok := lookupField(fields, n.key)
+
+	return ok
+}
+
+type inNode struct {
+	key    string
+	values []string
+}
+
+func (n inNode) eval(fields map[string]any) bool {
+	field, ok := lookupField(fields, n.key)
+	if !ok {
+		return false
+	}
+
+	for _, value := range n.values {
+		if equalField(field, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type compareNode struct {
+	pattern  *regexp.Regexp
+	key      string
+	operator string
+	value    string
+	number   float64
+}
+
+func newCompareNode(key, operator, value string) (node, error) {
+	comparison := compareNode{key: key, operator: operator, value: value}
+
+	switch operator {
+	case "~", "!~":
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("compile pattern of `%s`: %w", key, err)
+		}
+
+		comparison.pattern = pattern
+
+	case ">", ">=", "<", "<=":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("`%s %s` expects a number, got `%s`", key, operator, value)
+		}
+
+		comparison.number = number
+	}
+
+	return comparison, nil
+}
+
+func (n compareNode) eval(fields map[string]any) bool {
+	field, ok := lookupField(fields, n.key)
+	if !ok {
+		return false
+	}
+
+	switch n.operator {
+	case "==":
+		return equalField(field, n.value)
+
+	case "!=":
+		return !equalField(field, n.value)
+
+	case "~", "!~":
+		content, ok := stringOfField(field)
+
+		return ok && n.pattern.MatchString(content) == (n.operator == "~")
+
+	default:
+		number, ok := numberOfField(field)
+		if !ok {
+			return false
+		}
+
+		switch n.operator {
+		case ">":
+			return number > n.number
+		case ">=":
+			return number >= n.number
+		case "<":
+			return number < n.number
+		default:
+			return number <= n.number
+		}
+	}
+}
+
+// lookupField finds the value of a key, dots descending into nested objects when no key contains them
+func lookupField(fields map[string]any, key string) (any, bool) {
+	if value, ok := fieldOf(fields, key); ok {
+		return value, true
+	}
+
+	for index := strings.IndexByte(key, '.'); index != -1; {
+		if nested, ok := fieldOf(fields, key[:index]); ok {
+			if nestedFields, ok := nested.(map[string]any); ok {
+				if value, ok := lookupField(nestedFields, key[index+1:]); ok {
+					return value, true
+				}
+			}
+		}
+
+		next := strings.IndexByte(key[index+1:], '.')
+		if next == -1 {
+			break
+		}
+
+		index += next + 1
+	}
+
+	return nil, false
+}
+
+func fieldOf(fields map[string]any, key string) (any, bool) {
+	if value, ok := fields[key]; ok {
+		return value, true
+	}
+
+	for name, value := range fields {
+		if strings.EqualFold(name, key) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+func equalField(field any, value string) bool {
+	if fieldNumber, ok := field.(float64); ok {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return fieldNumber == number
+		}
+	}
+
+	content, ok := stringOfField(field)
+
+	return ok && strings.EqualFold(content, value)
+}
+
+func stringOfField(field any) (string, bool) {
+	switch value := field.(type) {
+	case string:
+		return value, true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case nil:
+		return "null", true
+	default:
+		return "", false
+	}
+}
+
+func numberOfField(field any) (float64, bool) {
+	switch value := field.(type) {
+	case float64:
+		return value, true
+	case string:
+		number, err := strconv.ParseFloat(value, 64)
+
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,136 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	content := `{"level":"ERROR","latency_ms":742,"path":"/api/users","http":{"status":503},"user.id":"42","cached":false}`
+
+	type args struct {
+		expression string
+		content    string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		"example": {
+			args{
+				expression: `level in (error,warn) && latency_ms > 500 && path ~ "^/api"`,
+				content:    content,
+			},
+			true,
+			false,
+		},
+		"nested key": {
+			args{
+				expression: "http.status >= 500",
+				content:    content,
+			},
+			true,
+			false,
+		},
+		"dotted key": {
+			args{
+				expression: "user.id == 42",
+				content:    content,
+			},
+			true,
+			false,
+		},
+		"or and not": {
+			args{
+				expression: "not (level == warn or cached == true)",
+				content:    content,
+			},
+			true,
+			false,
+		},
+		"not matching": {
+			args{
+				expression: "latency_ms < 100 || path !~ '^/api'",
+				content:    content,
+			},
+			false,
+			false,
+		},
+		"missing key": {
+			args{
+				expression: "trace_id != abc",
+				content:    content,
+			},
+			false,
+			false,
+		},
+		"presence": {
+			args{
+				expression: "http && !trace_id",
+				content:    content,
+			},
+			true,
+			false,
+		},
+		"text log": {
+			args{
+				expression: "level == error",
+				content:    "ERROR connection refused",
+			},
+			false,
+			false,
+		},
+		"json with stack trace": {
+			args{
+				expression: "level == error",
+				content:    content + "\n\tat com.example.App.main(App.java:12)",
+			},
+			true,
+			false,
+		},
+		"number expected": {
+			args{
+				expression: "latency_ms > fast",
+			},
+			false,
+			true,
+		},
+		"unterminated": {
+			args{
+				expression: `path ~ "^/api`,
+			},
+			false,
+			true,
+		},
+		"unbalanced": {
+			args{
+				expression: "(level == error",
+			},
+			false,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := ParseQuery(testCase.args.expression)
+
+			if gotErr := err != nil; gotErr != testCase.wantErr {
+				t.Fatalf("ParseQuery() = %v, want error %t", err, testCase.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got := query.Match(testCase.args.content); got != testCase.want {
+				t.Errorf("Match() = %t, want %t", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/client"
+	"github.com/ViBiOh/kmux/pkg/output"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// streamPosition tracks the timestamp of the last event read from a stream, for resuming it without duplicating lines.
+// The since time of a resumed stream is truncated to the second, so events are replayed from there and skipped until the position is reached.
+type streamPosition struct {
+	timestamp time.Time
+	count     int // events read at the timestamp
+	replay    int // events at the timestamp still to skip
+}
+
+// seen records the timestamp of an event, returning true when it has already been read before the stream was resumed
+func (p *streamPosition) seen(timestamp time.Time) bool {
+	switch {
+	case timestamp.IsZero():
+		return false
+
+	case timestamp.Before(p.timestamp):
+		return true
+
+	case timestamp.Equal(p.timestamp):
+		if p.replay > 0 {
+			p.replay--
+
+			return true
+		}
+
+		p.count++
+
+		return false
+
+	default:
+		p.timestamp, p.count, p.replay = timestamp, 1, 0
+
+		return false
+	}
+}
+
+func (p *streamPosition) resume() {
+	p.replay = p.count
+}
+
+type containerKey struct {
+	podKey
+	container string
+}
+
+// streamPositions shares the positions reached by the streams of the containers, for not printing their lines again in the crash logs
+type streamPositions struct {
+	positions map[containerKey]streamPosition
+	open      map[containerKey]chan struct{} // closed once the stream of the container has ended and its position stored
+	mutex     sync.Mutex
+}
+
+func newStreamPositions() *streamPositions {
+	return &streamPositions{
+		positions: make(map[containerKey]streamPosition),
+		open:      make(map[containerKey]chan struct{}),
+	}
+}
+
+// start marks the stream of the container as open, its position being loaded only once stored
+func (sp *streamPositions) start(meta output.Meta) {
+	if sp == nil {
+		return
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	key := metaContainerKey(meta)
+	if _, ok := sp.open[key]; !ok {
+		sp.open[key] = make(chan struct{})
+	}
+}
+
+// store records the position reached by the ended stream of the container
+func (sp *streamPositions) store(meta output.Meta, position streamPosition) {
+	if sp == nil {
+		return
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	key := metaContainerKey(meta)
+
+	if !position.timestamp.IsZero() {
+		sp.positions[key] = position
+	}
+
+	if open, ok := sp.open[key]; ok {
+		close(open)
+		delete(sp.open, key)
+	}
+}
+
+// load returns the position reached by the stream of the container, to be resumed from for skipping the lines already printed.
+// It waits for the open stream to end, e.g. draining the terminated instance of the container, or for the context to be done.
+func (sp *streamPositions) load(ctx context.Context, meta output.Meta) streamPosition {
+	if sp == nil {
+		return streamPosition{}
+	}
+
+	key := metaContainerKey(meta)
+
+	sp.mutex.Lock()
+	open, ok := sp.open[key]
+	sp.mutex.Unlock()
+
+	if ok {
+		select {
+		case <-open:
+		case <-ctx.Done():
+		}
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	position := sp.positions[key]
+	position.resume()
+
+	return position
+}
+
+// forget removes the positions of the containers of the pod
+func (sp *streamPositions) forget(meta output.Meta) {
+	if sp == nil {
+		return
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	for key := range sp.positions {
+		if key.podKey == (podKey{context: meta.Context, namespace: meta.Namespace, pod: meta.Pod}) {
+			delete(sp.positions, key)
+		}
+	}
+}
+
+func metaContainerKey(meta output.Meta) containerKey {
+	return containerKey{
+		podKey:    podKey{context: meta.Context, namespace: meta.Namespace, pod: meta.Pod},
+		container: meta.Container,
+	}
+}
+
+func (l Logger) openStream(ctx context.Context, kube client.Kube, namespace, name, container string, position streamPosition) (io.ReadCloser, error) {
+	options := l.logOptions(container)
+	options.Follow = !l.noFollow
+
+	if !position.timestamp.IsZero() {
+		sinceTime := metav1.NewTime(position.timestamp)
+
+		options.SinceSeconds = nil
+		options.SinceTime = &sinceTime
+		options.TailLines = nil
+	}
+
+	return kube.CoreV1().Pods(namespace).GetLogs(name, options).Stream(ctx)
+}
+
+// reopenStream waits for the container to still be running after its stream dropped, and resumes it from the position. It returns nil once the container or the context has ended.
+func (l Logger) reopenStream(ctx context.Context, kube client.Kube, outputter output.Outputter, namespace, name, container string, position *streamPosition, backoff *time.Duration) io.ReadCloser {
+	for {
+		if !waitBackoff(ctx, backoff) {
+			return nil
+		}
+
+		if !l.untilTime.IsZero() && time.Now().After(l.untilTime) {
+			return nil
+		}
+
+		running, err := containerRunning(ctx, kube, namespace, name, container)
+		if err != nil {
+			outputter.Warn("check container after log stream dropped: %s, retrying in %s", err, *backoff)
+			continue
+		}
+
+		if !running {
+			return nil
+		}
+
+		position.resume()
+
+		stream, err := l.openStream(ctx, kube, namespace, name, container, *position)
+		if err != nil {
+			outputter.Warn("resume log stream: %s, retrying in %s", err, *backoff)
+			continue
+		}
+
+		return stream
+	}
+}
+
+// containerRunning checks if the container of the pod is still running, a missing pod being ended
+func containerRunning(ctx context.Context, kube client.Kube, namespace, name, container string) (bool, error) {
+	pod, err := kube.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("get pod: %w", err)
+	}
+
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning {
+		return false, nil
+	}
+
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if status.Name == container {
+			return status.State.Running != nil, nil
+		}
+	}
+
+	return false, nil
+}
+
+func waitBackoff(ctx context.Context, backoff *time.Duration) bool {
+	timer := time.NewTimer(*backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		*backoff = min(*backoff*2, reconnectMaxBackoff)
+
+		return true
+	}
+}
@@ -0,0 +1,147 @@
+package log
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/client/clienttest"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestStreamPosition(t *testing.T) {
+	t.Parallel()
+
+	first := time.Date(2024, 1, 1, 10, 0, 0, 100, time.UTC)
+	second := first.Add(time.Millisecond)
+	third := second.Add(time.Millisecond)
+
+	type args struct {
+		before []time.Time
+		after  []time.Time
+	}
+
+	cases := map[string]struct {
+		args args
+		want []bool
+	}{
+		"replayed": {
+			args{
+				before: []time.Time{first, second},
+				after:  []time.Time{first, second, third},
+			},
+			[]bool{true, true, false},
+		},
+		"same timestamp": {
+			args{
+				before: []time.Time{first, second, second},
+				after:  []time.Time{second, second, second, third},
+			},
+			[]bool{true, true, false, false},
+		},
+		"no timestamp": {
+			args{
+				before: []time.Time{first},
+				after:  []time.Time{{}, first, second},
+			},
+			[]bool{false, true, false},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			var position streamPosition
+
+			for _, timestamp := range testCase.args.before {
+				position.seen(timestamp)
+			}
+
+			position.resume()
+
+			got := make([]bool, len(testCase.args.after))
+			for index, timestamp := range testCase.args.after {
+				got[index] = position.seen(timestamp)
+			}
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("seen() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestStreamPodResume(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	kube := clienttest.New("prod", "default", &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-1"},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{Name: "api", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}},
+		},
+	})
+
+	// the first stream drops after 3 lines, the resumed one replays the whole second as the API server truncates its since time
+	streams := []string{
+		logLines(start, "first", "second", "third"),
+		logLines(start, "first", "second", "third", "fourth", "fifth"),
+	}
+
+	var mutex sync.Mutex
+	var logOptions []*v1.PodLogOptions
+	var gets int
+
+	clienttest.Fake(kube).PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if action.GetSubresource() != "log" {
+			// the container ends after the second stream
+			gets++
+
+			if gets > 1 {
+				return true, nil, apierrors.NewNotFound(v1.Resource("pods"), "api-1")
+			}
+
+			return false, nil, nil
+		}
+
+		logOptions = append(logOptions, action.(k8stesting.GenericAction).GetValue().(*v1.PodLogOptions))
+
+		return true, &runtime.Unknown{Raw: []byte(streams[len(logOptions)-1])}, nil
+	})
+
+	directory := t.TempDir()
+
+	archive, err := NewArchive(directory, 0, 0)
+	if err != nil {
+		t.Fatalf("NewArchive() = %s", err)
+	}
+
+	logger := NewLogger("", "", "", time.Hour).WithRawOutput(true).WithArchive(archive)
+
+	logger.streamPod(context.Background(), kube, "default", "api-1", "api")
+
+	if len(logOptions) != 2 {
+		t.Fatalf("streamPod() opened %d streams, want 2", len(logOptions))
+	}
+
+	if sinceTime := logOptions[1].SinceTime; sinceTime == nil || !sinceTime.Time.Equal(start.Add(2*time.Millisecond)) || logOptions[1].SinceSeconds != nil {
+		t.Errorf("streamPod() resumed since %v, want %s", sinceTime, start.Add(2*time.Millisecond))
+	}
+
+	want := []string{"first", "second", "third", "fourth", "fifth"}
+	if got := archivedLines(t, archive, directory, logger.logOutputter(kube, "default", "api-1", "api")); !reflect.DeepEqual(got, want) {
+		t.Errorf("streamPod() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+	"github.com/fatih/color"
+)
+
+var ruleColors = map[string]*color.Color{
+	"red":     output.Red,
+	"yellow":  output.Yellow,
+	"white":   output.White,
+	"green":   output.Green,
+	"blue":    output.Blue,
+	"cyan":    output.Cyan,
+	"magenta": output.Magenta,
+}
+
+// ColorRuleConfig is a coloring rule as written in the config file
+type ColorRuleConfig struct {
+	Min      *float64
+	Max      *float64
+	Key      string
+	Pattern  string
+	Color    string
+	Severity string
+	Values   []string
+}
+
+// ColorRule colors the logs whose key value is one of the values, in the range or matching the pattern, or the whole line matching the pattern when there is no key.
+// The severity is the color used for `--grepColor` filtering.
+type ColorRule struct {
+	min      *float64
+	max      *float64
+	pattern  *regexp.Regexp
+	color    *color.Color
+	severity *color.Color
+	key      string
+	values   []string
+}
+
+func NewColorRule(config ColorRuleConfig) (ColorRule, error) {
+	rule := ColorRule{
+		key:    config.Key,
+		values: config.Values,
+		min:    config.Min,
+		max:    config.Max,
+	}
+
+	var ok bool
+
+	rule.color, ok = ruleColors[strings.ToLower(config.Color)]
+	if !ok {
+		return rule, fmt.Errorf("unknown color `%s`", config.Color)
+	}
+
+	severity := config.Severity
+	if len(severity) == 0 {
+		severity = config.Color
+	}
+
+	rule.severity = ColorFromName(strings.ToLower(severity))
+	if rule.severity == nil {
+		if len(config.Severity) != 0 {
+			return rule, fmt.Errorf("unknown severity `%s`, expected red, yellow, white or green", config.Severity)
+		}
+
+		rule.severity = output.White
+	}
+
+	if len(config.Pattern) != 0 {
+		pattern, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return rule, fmt.Errorf("compile pattern: %w", err)
+		}
+
+		rule.pattern = pattern
+	}
+
+	if len(rule.key) == 0 && rule.pattern == nil {
+		return rule, errors.New("a key or a pattern is required")
+	}
+
+	if len(rule.key) != 0 && len(rule.values) == 0 && rule.min == nil && rule.max == nil && rule.pattern == nil {
+		return rule, fmt.Errorf("values, min, max or pattern are required for key `%s`", rule.key)
+	}
+
+	return rule, nil
+}
+
+func (cr ColorRule) match(content string) bool {
+	if len(cr.key) == 0 {
+		return cr.pattern.MatchString(content)
+	}
+
+	value, ok := fieldValue(content, cr.key)
+	if !ok {
+		return false
+	}
+
+	if len(cr.values) != 0 {
+		text, ok := stringOfField(value)
+		if !ok || !matchKey(text, cr.values) {
+			return false
+		}
+	}
+
+	if cr.min != nil || cr.max != nil {
+		number, ok := numberOfField(value)
+		if !ok || cr.min != nil && number < *cr.min || cr.max != nil && number > *cr.max {
+			return false
+		}
+	}
+
+	if cr.pattern != nil {
+		text, ok := stringOfField(value)
+		if !ok || !cr.pattern.MatchString(text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// colorOfRules returns the color and severity of the first matching rule
+func colorOfRules(rules []ColorRule, content string) (*color.Color, *color.Color, bool) {
+	for _, rule := range rules {
+		if rule.match(content) {
+			return rule.color, rule.severity, true
+		}
+	}
+
+	return nil, nil, false
+}
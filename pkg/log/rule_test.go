@@ -0,0 +1,109 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+	"github.com/fatih/color"
+)
+
+func TestColorRule(t *testing.T) {
+	t.Parallel()
+
+	minimum := 500.0
+
+	type args struct {
+		config  ColorRuleConfig
+		content string
+	}
+
+	cases := map[string]struct {
+		args         args
+		want         *color.Color
+		wantSeverity *color.Color
+		wantErr      bool
+	}{
+		"values": {
+			args{
+				config:  ColorRuleConfig{Key: "level", Values: []string{"alert", "emergency"}, Color: "magenta", Severity: "red"},
+				content: `{"level":"ALERT"}`,
+			},
+			output.Magenta,
+			output.Red,
+			false,
+		},
+		"default severity": {
+			args{
+				config:  ColorRuleConfig{Key: "level", Values: []string{"notice"}, Color: "cyan"},
+				content: `level=notice msg="disk almost full"`,
+			},
+			output.Cyan,
+			output.White,
+			false,
+		},
+		"range": {
+			args{
+				config:  ColorRuleConfig{Key: "http.latency_ms", Min: &minimum, Color: "yellow"},
+				content: `{"http":{"latency_ms":742}}`,
+			},
+			output.Yellow,
+			output.Yellow,
+			false,
+		},
+		"out of range": {
+			args{
+				config:  ColorRuleConfig{Key: "http.latency_ms", Min: &minimum, Color: "yellow"},
+				content: `{"http":{"latency_ms":42}}`,
+			},
+			nil,
+			nil,
+			false,
+		},
+		"line pattern": {
+			args{
+				config:  ColorRuleConfig{Pattern: "OOMKilled", Color: "red"},
+				content: "container OOMKilled, restarting",
+			},
+			output.Red,
+			output.Red,
+			false,
+		},
+		"unknown color": {
+			args{
+				config: ColorRuleConfig{Pattern: "OOMKilled", Color: "purple"},
+			},
+			nil,
+			nil,
+			true,
+		},
+		"no condition": {
+			args{
+				config: ColorRuleConfig{Key: "level", Color: "red"},
+			},
+			nil,
+			nil,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := NewColorRule(testCase.args.config)
+
+			if gotErr := err != nil; gotErr != testCase.wantErr {
+				t.Fatalf("NewColorRule() = %v, want error %t", err, testCase.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, gotSeverity, _ := colorOfRules([]ColorRule{rule}, testCase.args.content)
+			if got != testCase.want || gotSeverity != testCase.wantSeverity {
+				t.Errorf("colorOfRules() = (%v, %v), want (%v, %v)", got, gotSeverity, testCase.want, testCase.wantSeverity)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+const minSortTick = 10 * time.Millisecond
+
+type sortedEntry struct {
+	timestamp time.Time
+	arrival   time.Time
+	emit      func()
+	sequence  uint64
+}
+
+type sortedEntries []sortedEntry
+
+func (se sortedEntries) Len() int {
+	return len(se)
+}
+
+func (se sortedEntries) Less(i, j int) bool {
+	if se[i].timestamp.Equal(se[j].timestamp) {
+		return se[i].sequence < se[j].sequence
+	}
+
+	return se[i].timestamp.Before(se[j].timestamp)
+}
+
+func (se sortedEntries) Swap(i, j int) {
+	se[i], se[j] = se[j], se[i]
+}
+
+func (se *sortedEntries) Push(item any) {
+	*se = append(*se, item.(sortedEntry))
+}
+
+func (se *sortedEntries) Pop() any {
+	old := *se
+	item := old[len(old)-1]
+	*se = old[:len(old)-1]
+
+	return item
+}
+
+// Sorter buffers log lines of every pod and context during a reorder window, then emits them ordered by timestamp
+type Sorter struct {
+	done     chan struct{}
+	entries  sortedEntries
+	window   time.Duration
+	sequence uint64
+	mutex    sync.Mutex
+	stopped  sync.WaitGroup
+	closed   bool
+}
+
+func NewSorter(window time.Duration) *Sorter {
+	s := &Sorter{
+		done:   make(chan struct{}),
+		window: window,
+	}
+
+	s.stopped.Go(s.start)
+
+	return s
+}
+
+func (s *Sorter) start() {
+	ticker := time.NewTicker(max(s.window/4, minSortTick))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.flush(false)
+		}
+	}
+}
+
+// Push holds the emit function until the reorder window of the line is over, lines without timestamp are ordered by arrival
+func (s *Sorter) Push(timestamp time.Time, emit func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		emit()
+		return
+	}
+
+	arrival := time.Now()
+	if timestamp.IsZero() {
+		timestamp = arrival
+	}
+
+	s.sequence++
+	heap.Push(&s.entries, sortedEntry{
+		timestamp: timestamp,
+		arrival:   arrival,
+		emit:      emit,
+		sequence:  s.sequence,
+	})
+}
+
+// flush emits the oldest lines having waited for the whole window, or all of them
+func (s *Sorter) flush(all bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cutoff := time.Now().Add(-s.window)
+
+	for len(s.entries) > 0 {
+		if !all && s.entries[0].arrival.After(cutoff) {
+			return
+		}
+
+		heap.Pop(&s.entries).(sortedEntry).emit()
+	}
+}
+
+// Close emits the remaining lines, next ones are emitted directly
+func (s *Sorter) Close() {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+
+	s.closed = true
+	s.mutex.Unlock()
+
+	close(s.done)
+	s.stopped.Wait()
+
+	s.flush(true)
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSorter(t *testing.T) {
+	t.Parallel()
+
+	origin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type line struct {
+		timestamp time.Time
+		text      string
+	}
+
+	type args struct {
+		lines []line
+	}
+
+	cases := map[string]struct {
+		args args
+		want []string
+	}{
+		"ordered": {
+			args{
+				lines: []line{
+					{origin, "first"},
+					{origin.Add(time.Second), "second"},
+				},
+			},
+			[]string{"first", "second"},
+		},
+		"out of order": {
+			args{
+				lines: []line{
+					{origin.Add(2 * time.Second), "eu third"},
+					{origin, "us first"},
+					{origin.Add(time.Second), "eu second"},
+				},
+			},
+			[]string{"us first", "eu second", "eu third"},
+		},
+		"same timestamp": {
+			args{
+				lines: []line{
+					{origin, "eu"},
+					{origin, "us"},
+				},
+			},
+			[]string{"eu", "us"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			sorter := NewSorter(time.Hour)
+
+			var mutex sync.Mutex
+			var got []string
+
+			for _, line := range testCase.args.lines {
+				sorter.Push(line.timestamp, func() {
+					mutex.Lock()
+					defer mutex.Unlock()
+
+					got = append(got, line.text)
+				})
+			}
+
+			sorter.Close()
+
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Sorter() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
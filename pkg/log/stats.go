@@ -0,0 +1,268 @@
+package log
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+	"github.com/ViBiOh/kmux/pkg/table"
+	"github.com/fatih/color"
+)
+
+const maxStatsMessageLength = 100
+
+// clearScreen moves the cursor home and clears the terminal, for redrawing the stats in place
+const clearScreen = "\033[H\033[2J"
+
+// statsWidths are the default widths of the context, namespace, pod and lines columns, severities' ones following
+var statsWidths = []uint64{20, 20, 40, 8}
+
+var (
+	messageKeys = []string{"msg", "message"}
+
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b(0x)?[0-9a-f]{12,}\b`)
+	numberPattern = regexp.MustCompile(`\d+(\.\d+)?`)
+)
+
+type severityColumn struct {
+	color *color.Color
+	name  string
+}
+
+// severities in the order of the columns, with their names, custom rules having one of them
+var severities = []severityColumn{
+	{output.Red, "error"},
+	{output.Yellow, "warn"},
+	{output.White, "info"},
+	{output.Green, "debug"},
+}
+
+// podKey identifies a pod across contexts
+type podKey struct {
+	context   string
+	namespace string
+	pod       string
+}
+
+type podStats struct {
+	severities map[*color.Color]uint64
+	messages   map[string]uint64
+	lines      uint64
+}
+
+type messageCount struct {
+	message string
+	count   uint64
+}
+
+// Stats counts the log lines of each pod by severity and normalized message, instead of printing them
+type Stats struct {
+	done     chan struct{}
+	pods     map[podKey]*podStats
+	interval time.Duration
+	top      int
+	mutex    sync.Mutex
+	stopped  sync.WaitGroup
+	closed   bool
+}
+
+// NewStats prints the stats every interval, only on close when the interval is 0, with the top most frequent messages of each pod.
+// As text, the stats are printed every interval only on a terminal, redrawn in place.
+func NewStats(interval time.Duration, top int) *Stats {
+	s := &Stats{
+		done:     make(chan struct{}),
+		pods:     make(map[podKey]*podStats),
+		interval: interval,
+		top:      top,
+	}
+
+	if interval > 0 && (output.IsStructured() || stdoutTerminal()) {
+		s.stopped.Go(s.start)
+	}
+
+	return s
+}
+
+func (s *Stats) start() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.print(fmt.Sprintf("Stats at %s", time.Now().Format(time.TimeOnly)), true)
+		}
+	}
+}
+
+func (s *Stats) Add(meta output.Meta, severity *color.Color, text string) {
+	key := podKey{context: meta.Context, namespace: meta.Namespace, pod: meta.Pod}
+	message := normalizeMessage(text)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pod, ok := s.pods[key]
+	if !ok {
+		pod = &podStats{
+			severities: make(map[*color.Color]uint64),
+			messages:   make(map[string]uint64),
+		}
+
+		s.pods[key] = pod
+	}
+
+	pod.lines++
+	pod.severities[severity]++
+	pod.messages[message]++
+}
+
+// Close prints the final summary
+func (s *Stats) Close() {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+
+	s.closed = true
+	s.mutex.Unlock()
+
+	close(s.done)
+	s.stopped.Wait()
+
+	s.print("Summary", false)
+}
+
+func (s *Stats) print(title string, redraw bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := make([]podKey, 0, len(s.pods))
+	for key := range s.pods {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b podKey) int {
+		return cmp.Or(cmp.Compare(a.context, b.context), cmp.Compare(a.namespace, b.namespace), cmp.Compare(a.pod, b.pod))
+	})
+
+	if output.IsStructured() {
+		for _, key := range keys {
+			s.record(key, s.pods[key])
+		}
+
+		return
+	}
+
+	var builder strings.Builder
+
+	if redraw {
+		builder.WriteString(clearScreen)
+	}
+
+	fmt.Fprintln(&builder, output.Yellow.Sprintf("=== %s ===", title))
+
+	statsTable := table.New(slices.Clone(statsWidths))
+
+	header := []table.Cell{table.NewCell("CONTEXT"), table.NewCell("NAMESPACE"), table.NewCell("POD"), table.NewCell("LINES")}
+	for _, severity := range severities {
+		header = append(header, table.NewCellColor(strings.ToUpper(severity.name), severity.color))
+	}
+
+	fmt.Fprintln(&builder, statsTable.Format(header))
+
+	for _, key := range keys {
+		pod := s.pods[key]
+
+		row := []table.Cell{table.NewCell(key.context), table.NewCell(key.namespace), table.NewCell(key.pod), table.NewCell(strconv.FormatUint(pod.lines, 10))}
+		for _, severity := range severities {
+			row = append(row, table.NewCellColor(strconv.FormatUint(pod.severities[severity.color], 10), severity.color))
+		}
+
+		fmt.Fprintln(&builder, statsTable.Format(row))
+
+		for _, message := range pod.topMessages(s.top) {
+			fmt.Fprintf(&builder, "  %8d  %s\n", message.count, message.message)
+		}
+	}
+
+	// printed at once, for not being interleaved with other outputs
+	output.Std("", "%s", builder.String())
+}
+
+// stdoutTerminal checks if the standard output is a terminal, where the stats can be redrawn in place
+func stdoutTerminal() bool {
+	info, err := os.Stdout.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (s *Stats) record(key podKey, pod *podStats) {
+	fields := map[string]any{
+		"lines": pod.lines,
+	}
+
+	for _, severity := range severities {
+		fields[severity.name] = pod.severities[severity.color]
+	}
+
+	var messages []map[string]any
+	for _, message := range pod.topMessages(s.top) {
+		messages = append(messages, map[string]any{"message": message.message, "count": message.count})
+	}
+
+	fields["messages"] = messages
+
+	output.NewOutputter(key.context).WithMeta(output.Meta{Namespace: key.namespace, Pod: key.pod}).Record(fields, "")
+}
+
+func (ps *podStats) topMessages(top int) []messageCount {
+	messages := make([]messageCount, 0, len(ps.messages))
+	for message, count := range ps.messages {
+		messages = append(messages, messageCount{message: message, count: count})
+	}
+
+	slices.SortFunc(messages, func(a, b messageCount) int {
+		return cmp.Or(cmp.Compare(b.count, a.count), cmp.Compare(a.message, b.message))
+	})
+
+	return messages[:min(max(top, 0), len(messages))]
+}
+
+// normalizeLine replaces the variable parts of a line, e.g. timestamps, ids or durations
+func normalizeLine(text string) string {
+	text = uuidPattern.ReplaceAllString(text, "<uuid>")
+	text = hexPattern.ReplaceAllString(text, "<hex>")
+
+	return numberPattern.ReplaceAllString(text, "<n>")
+}
+
+// normalizeMessage extracts the message of JSON or logfmt logs, and replaces the variable parts, e.g. ids or durations, for grouping similar ones
+func normalizeMessage(text string) string {
+	message, _, _ := strings.Cut(text, "\n")
+
+	if value, ok := fieldValue(message, messageKeys...); ok {
+		if content, ok := stringOfField(value); ok {
+			message = content
+		}
+	}
+
+	message = normalizeLine(message)
+
+	if runes := []rune(message); len(runes) > maxStatsMessageLength {
+		message = string(runes[:maxStatsMessageLength]) + "…"
+	}
+
+	return message
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		text string
+	}
+
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"text": {
+			args{
+				text: "GET /api/users/42 took 12.5ms",
+			},
+			"GET /api/users/<n> took <n>ms",
+		},
+		"json": {
+			args{
+				text: `{"level":"error","msg":"user 3f2b8c1e-9d4a-4e5b-8c7d-1a2b3c4d5e6f not found","status":404}`,
+			},
+			"user <uuid> not found",
+		},
+		"logfmt": {
+			args{
+				text: `level=info message="commit 9fceb02d0ae598e95dc970b74767f19372d61af8 deployed"`,
+			},
+			"commit <hex> deployed",
+		},
+		"multiline": {
+			args{
+				text: "panic: index out of range [3]\n\tmain.go:12",
+			},
+			"panic: index out of range [<n>]",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := normalizeMessage(testCase.args.text); got != testCase.want {
+				t.Errorf("normalizeMessage() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	stats := NewStats(0, 2)
+
+	meta := output.Meta{Context: "production", Namespace: "default", Pod: "api-1"}
+
+	stats.Add(meta, output.White, "GET /api/users/1")
+	stats.Add(meta, output.White, "GET /api/users/2")
+	stats.Add(meta, output.Red, `{"level":"error","msg":"timeout after 30s"}`)
+	stats.Add(meta, output.White, "GET /health")
+	stats.Add(meta, output.Red, `{"level":"error","msg":"timeout after 10s"}`)
+	stats.Add(meta, output.White, "GET /api/users/3")
+	stats.Add(output.Meta{Context: "production", Namespace: "default", Pod: "api-2"}, output.Yellow, "retrying")
+
+	pod := stats.pods[podKey{context: "production", namespace: "default", pod: "api-1"}]
+
+	if pod.lines != 6 || pod.severities[output.Red] != 2 || pod.severities[output.White] != 4 {
+		t.Errorf("Add() = %d lines, %d red, %d white, want 6 lines, 2 red, 4 white", pod.lines, pod.severities[output.Red], pod.severities[output.White])
+	}
+
+	want := []messageCount{
+		{message: "GET /api/users/<n>", count: 3},
+		{message: "timeout after <n>s", count: 2},
+	}
+
+	if got := pod.topMessages(stats.top); !reflect.DeepEqual(got, want) {
+		t.Errorf("topMessages() = %+v, want %+v", got, want)
+	}
+
+	if len(stats.pods) != 2 {
+		t.Errorf("Add() = %d pods, want 2", len(stats.pods))
+	}
+
+	rule, err := NewColorRule(ColorRuleConfig{Pattern: "audit", Color: "magenta", Severity: "red"})
+	if err != nil {
+		t.Fatalf("NewColorRule() = %s", err)
+	}
+
+	logger := NewLogger("", "", "", time.Hour).WithColorRules([]ColorRule{rule}).WithStats(stats)
+	outputter := output.NewOutputter("production").WithMeta(output.Meta{Namespace: "monitoring", Pod: "api-1"})
+
+	logger.printLogs(strings.NewReader(logLines(time.Now(), "audit", "GET /health")), outputter, &streamPosition{})
+
+	if got := stats.pods[podKey{context: "production", namespace: "monitoring", pod: "api-1"}]; got.severities[output.Red] != 1 || got.severities[output.White] != 1 {
+		t.Errorf("printLogs() = %d red, %d white, want the rule's severity counted", got.severities[output.Red], got.severities[output.White])
+	}
+
+	if len(stats.pods) != 3 {
+		t.Errorf("Add() = %d pods, want 3 with the same name in another namespace", len(stats.pods))
+	}
+
+	if got := pod.topMessages(-1); len(got) != 0 {
+		t.Errorf("topMessages(-1) = %+v, want none", got)
+	}
+}
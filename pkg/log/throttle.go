@@ -0,0 +1,189 @@
+package log
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+)
+
+// deduper collapses the consecutive lines of a stream having the same normalized content during the window.
+// The repeats are notified when a different line comes, or once the window has elapsed for a quiet stream.
+// The window is measured with the timestamps of the lines, then with the wall-clock once the stream is quiet.
+type deduper struct {
+	since    time.Time
+	last     time.Time
+	pushed   time.Time // wall-clock time of the last repeat
+	timer    *time.Timer
+	notify   func(time.Time, uint)
+	key      string
+	window   time.Duration
+	repeated uint
+	mutex    sync.Mutex
+}
+
+func newDeduper(window time.Duration, notify func(timestamp time.Time, repeated uint)) *deduper {
+	return &deduper{
+		window: window,
+		notify: notify,
+	}
+}
+
+// push returns true when the line repeats the previous one and must be skipped, notifying the repeats of the previous one otherwise
+func (d *deduper) push(timestamp time.Time, text string) bool {
+	now := timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	key := normalizeLine(text)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if key == d.key && now.Sub(d.since) < d.window {
+		d.repeated++
+		d.last = now
+		d.pushed = time.Now()
+
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window-now.Sub(d.since), d.expire)
+		}
+
+		return true
+	}
+
+	d.flushLocked()
+	d.key, d.since = key, now
+
+	return false
+}
+
+// expire notifies the repeats once the window has elapsed without a different line, the next line being printed even if it's the same
+func (d *deduper) expire() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.timer == nil {
+		return
+	}
+
+	// lines replayed from history are quicker than their timestamps, the window is only over once they're quiet
+	if remaining := d.window - d.last.Sub(d.since) - time.Since(d.pushed); remaining > 0 {
+		d.timer.Reset(remaining)
+
+		return
+	}
+
+	d.flushLocked()
+	d.key = ""
+}
+
+// flush notifies the repeats of the last line, when the stream ends
+func (d *deduper) flush() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.flushLocked()
+}
+
+func (d *deduper) flushLocked() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	if d.repeated > 0 {
+		d.notify(d.last, d.repeated)
+	}
+
+	d.repeated = 0
+}
+
+type podLimit struct {
+	second  time.Time
+	count   uint
+	lines   uint64
+	dropped uint
+}
+
+// Limiter samples the lines of each pod and limits their number per second
+type Limiter struct {
+	pods         map[podKey]*podLimit
+	every        uint64
+	maxPerSecond uint
+	mutex        sync.Mutex
+}
+
+// NewLimiter keeps 1 line out of round(1/sample) when sample is below 1, and at most maxPerSecond lines per second when not 0
+func NewLimiter(sample float64, maxPerSecond uint) *Limiter {
+	var every uint64 = 1
+	if sample > 0 && sample < 1 {
+		every = uint64(math.Round(1 / sample))
+	}
+
+	return &Limiter{
+		pods:         make(map[podKey]*podLimit),
+		every:        every,
+		maxPerSecond: maxPerSecond,
+	}
+}
+
+// Allow returns true when the line of the pod is printed, with the number of lines dropped by the rate limit since the last printed one.
+// The time is the one of the line when known, for limiting the lines replayed from history as they were written.
+func (l *Limiter) Allow(meta output.Meta, now time.Time) (bool, uint) {
+	key := podKey{context: meta.Context, namespace: meta.Namespace, pod: meta.Pod}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	pod, ok := l.pods[key]
+	if !ok {
+		pod = &podLimit{}
+		l.pods[key] = pod
+	}
+
+	pod.lines++
+	if (pod.lines-1)%l.every != 0 {
+		return false, 0
+	}
+
+	if l.maxPerSecond == 0 {
+		return true, 0
+	}
+
+	// lines of the containers of a pod are not ordered between them, an earlier one is counted in the current second
+	if second := now.Truncate(time.Second); second.After(pod.second) {
+		pod.second, pod.count = second, 0
+	}
+
+	if pod.count >= l.maxPerSecond {
+		pod.dropped++
+
+		return false, 0
+	}
+
+	pod.count++
+
+	dropped := pod.dropped
+	pod.dropped = 0
+
+	return true, dropped
+}
+
+// Flush returns the number of lines of the pod dropped by the rate limit since the last printed one, when its stream ends
+func (l *Limiter) Flush(meta output.Meta) uint {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	pod, ok := l.pods[podKey{context: meta.Context, namespace: meta.Namespace, pod: meta.Pod}]
+	if !ok {
+		return 0
+	}
+
+	dropped := pod.dropped
+	pod.dropped = 0
+
+	return dropped
+}
@@ -0,0 +1,258 @@
+package log
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/output"
+)
+
+// skipped marks a skipped line in the results of TestDeduper
+const skipped = ^uint(0)
+
+func TestDeduper(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	type line struct {
+		timestamp time.Time
+		text      string
+	}
+
+	type args struct {
+		lines []line
+	}
+
+	cases := map[string]struct {
+		args      args
+		want      []uint
+		wantFlush uint
+	}{
+		"distinct": {
+			args{
+				lines: []line{
+					{start, "Starting server"},
+					{start.Add(time.Millisecond), "Listening on :8080"},
+				},
+			},
+			[]uint{0, 0},
+			0,
+		},
+		"repeated with ids": {
+			args{
+				lines: []line{
+					{start, "connection 12 refused"},
+					{start.Add(time.Millisecond), "connection 13 refused"},
+					{start.Add(2 * time.Millisecond), "connection 14 refused"},
+					{start.Add(3 * time.Millisecond), "Listening on :8080"},
+				},
+			},
+			[]uint{0, skipped, skipped, 2},
+			0,
+		},
+		"window elapsed": {
+			args{
+				lines: []line{
+					{start, "connection refused"},
+					{start.Add(time.Millisecond), "connection refused"},
+					{start.Add(2 * time.Second), "connection refused"},
+					{start.Add(2*time.Second + time.Millisecond), "connection refused"},
+				},
+			},
+			[]uint{0, skipped, 1, skipped},
+			1,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			var notified uint
+
+			dedupe := newDeduper(time.Second, func(_ time.Time, repeated uint) {
+				notified = repeated
+			})
+
+			got := make([]uint, len(testCase.args.lines))
+			for index, line := range testCase.args.lines {
+				notified = 0
+
+				if dedupe.push(line.timestamp, line.text) {
+					notified = skipped
+				}
+
+				got[index] = notified
+			}
+
+			notified = 0
+			dedupe.flush()
+
+			if gotFlush := notified; !reflect.DeepEqual(got, testCase.want) || gotFlush != testCase.wantFlush {
+				t.Errorf("push() = %v then %d, want %v then %d", got, gotFlush, testCase.want, testCase.wantFlush)
+			}
+		})
+	}
+}
+
+func TestDeduperQuiet(t *testing.T) {
+	t.Parallel()
+
+	notified := make(chan uint, 1)
+
+	dedupe := newDeduper(10*time.Millisecond, func(_ time.Time, repeated uint) {
+		notified <- repeated
+	})
+
+	for _, skip := range []bool{false, true, true} {
+		if got := dedupe.push(time.Time{}, "connection refused"); got != skip {
+			t.Errorf("push() = %t, want %t", got, skip)
+		}
+	}
+
+	select {
+	case got := <-notified:
+		if got != 2 {
+			t.Errorf("notified %d repeats, want 2", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("repeats not notified on a quiet stream")
+	}
+
+	if dedupe.push(time.Time{}, "connection refused") {
+		t.Error("push() = true after the notice, want false")
+	}
+
+	dedupe.flush()
+
+	if len(notified) != 0 {
+		t.Errorf("notified %d repeats on flush, want none", <-notified)
+	}
+}
+
+func TestDeduperReplay(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	notified := make(chan uint, 1)
+
+	dedupe := newDeduper(50*time.Millisecond, func(_ time.Time, repeated uint) {
+		notified <- repeated
+	})
+
+	// lines written within the window, replayed slower than the window
+	for index, skip := range []bool{false, true, true, true} {
+		if got := dedupe.push(start.Add(time.Duration(index)*time.Millisecond), "connection refused"); got != skip {
+			t.Errorf("push(#%d) = %t, want %t", index, got, skip)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	select {
+	case got := <-notified:
+		if got != 3 {
+			t.Errorf("notified %d repeats, want 3", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("repeats not notified once the replay is quiet")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	type args struct {
+		sample       float64
+		maxPerSecond uint
+		lines        []time.Time
+	}
+
+	cases := map[string]struct {
+		args        args
+		want        []bool
+		wantDropped uint
+		wantFlush   uint
+	}{
+		"unlimited": {
+			args{
+				sample: 1,
+				lines:  []time.Time{now, now, now},
+			},
+			[]bool{true, true, true},
+			0,
+			0,
+		},
+		"sampled": {
+			args{
+				sample: 0.34,
+				lines:  []time.Time{now, now, now, now, now, now, now},
+			},
+			[]bool{true, false, false, true, false, false, true},
+			0,
+			0,
+		},
+		"rate limited": {
+			args{
+				sample:       1,
+				maxPerSecond: 2,
+				lines:        []time.Time{now, now, now, now, now.Add(time.Second)},
+			},
+			[]bool{true, true, false, false, true},
+			2,
+			0,
+		},
+		"dropped at the end": {
+			args{
+				sample:       1,
+				maxPerSecond: 2,
+				lines:        []time.Time{now, now, now, now},
+			},
+			[]bool{true, true, false, false},
+			0,
+			2,
+		},
+		"earlier line of another container": {
+			args{
+				sample:       1,
+				maxPerSecond: 1,
+				lines:        []time.Time{now.Add(time.Second), now, now.Add(time.Second)},
+			},
+			[]bool{true, false, false},
+			0,
+			2,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := NewLimiter(testCase.args.sample, testCase.args.maxPerSecond)
+			meta := output.Meta{Context: "production", Namespace: "default", Pod: "api-1"}
+
+			var gotDropped uint
+
+			got := make([]bool, len(testCase.args.lines))
+			for index, at := range testCase.args.lines {
+				var dropped uint
+
+				got[index], dropped = limiter.Allow(meta, at)
+				gotDropped += dropped
+			}
+
+			if !reflect.DeepEqual(got, testCase.want) || gotDropped != testCase.wantDropped {
+				t.Errorf("Allow() = %v with %d dropped, want %v with %d dropped", got, gotDropped, testCase.want, testCase.wantDropped)
+			}
+
+			if gotFlush := limiter.Flush(meta); gotFlush != testCase.wantFlush {
+				t.Errorf("Flush() = %d, want %d", gotFlush, testCase.wantFlush)
+			}
+		})
+	}
+}
@@ -34,12 +34,25 @@ func Info(prefix, format string, args ...any) {
 }
 
 func Fatal(format string, args ...any) {
+	Exit(1, format, args...)
+}
+
+func Exit(code int, format string, args ...any) {
 	_, _ = fmt.Fprint(os.Stderr, Red.Sprintf(format, args...))
-	os.Exit(1)
+	os.Exit(code)
+}
+
+// Meta describes where an output comes from, for structured records
+type Meta struct {
+	Context   string
+	Namespace string
+	Pod       string
+	Container string
 }
 
 type Outputter struct {
 	prefix string
+	meta   Meta
 }
 
 func NewOutputter(name string) Outputter {
@@ -51,6 +64,9 @@ func NewOutputter(name string) Outputter {
 
 	return Outputter{
 		prefix: prefix,
+		meta: Meta{
+			Context: name,
+		},
 	}
 }
 
@@ -59,10 +75,46 @@ func (o Outputter) Write(payload []byte) (int, error) {
 	return len(payload), nil
 }
 
+// Std outputs the content, as a record with a `message` field when the format is structured
 func (o Outputter) Std(format string, args ...any) {
+	if IsStructured() {
+		o.Record(map[string]any{"message": fmt.Sprintf(format, args...)}, "")
+		return
+	}
+
 	Std(o.prefix, format, args...)
 }
 
+// Record outputs the fields along with the metadata when the format is structured, the formatted text otherwise, if any
+func (o Outputter) Record(fields map[string]any, format string, args ...any) {
+	if !IsStructured() {
+		if len(format) != 0 {
+			Std(o.prefix, format, args...)
+		}
+
+		return
+	}
+
+	record := make(map[string]any, len(fields)+4)
+
+	for key, value := range map[string]string{
+		"context":   o.meta.Context,
+		"namespace": o.meta.Namespace,
+		"pod":       o.meta.Pod,
+		"container": o.meta.Container,
+	} {
+		if len(value) != 0 {
+			record[key] = value
+		}
+	}
+
+	for key, value := range fields {
+		record[key] = value
+	}
+
+	outputRecord(record)
+}
+
 func (o Outputter) Err(format string, args ...any) {
 	Err(o.prefix, format, args...)
 }
@@ -84,3 +136,28 @@ func (o Outputter) Child(noPrefix bool, prefix string) Outputter {
 
 	return o
 }
+
+func (o Outputter) Meta() Meta {
+	return o.meta
+}
+
+// WithMeta sets the non-empty metadata fields
+func (o Outputter) WithMeta(meta Meta) Outputter {
+	if len(meta.Context) != 0 {
+		o.meta.Context = meta.Context
+	}
+
+	if len(meta.Namespace) != 0 {
+		o.meta.Namespace = meta.Namespace
+	}
+
+	if len(meta.Pod) != 0 {
+		o.meta.Pod = meta.Pod
+	}
+
+	if len(meta.Container) != 0 {
+		o.meta.Container = meta.Container
+	}
+
+	return o
+}
@@ -1,49 +1,120 @@
 package output
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
 )
 
 type event struct {
 	prefix  string
 	message string
 	std     bool
+	record  bool
 }
 
 var (
 	done       = make(chan struct{})
 	outputChan = make(chan event, 128)
+	closeMutex sync.RWMutex
+	closed     bool
+	format     string
 )
 
 func init() {
 	go startPrinter()
 }
 
+// SetFormat configures the format of records, `json` for an array, `ndjson` for one record per line, anything else for text. It must be called before any output.
+func SetFormat(name string) {
+	switch name {
+	case FormatJSON, FormatNDJSON:
+		format = name
+		color.NoColor = true
+	default:
+		format = ""
+	}
+}
+
+func IsStructured() bool {
+	return len(format) != 0
+}
+
 func startPrinter() {
 	defer close(done)
 
-	for outputEvent := range outputChan {
+	printEvents(outputChan, os.Stdout, os.Stderr)
+}
+
+// printEvents prints the events until the channel is closed, framing the records as a JSON array or one per line
+func printEvents(events <-chan event, stdout, stderr io.Writer) {
+	var records uint64
+
+	for outputEvent := range events {
+		if outputEvent.record {
+			printRecord(stdout, outputEvent.message, records)
+			records++
+
+			continue
+		}
+
 		message := strings.TrimSuffix(outputEvent.message, "\n")
 
 		for line := range strings.SplitSeq(message, "\n") {
 			if len(outputEvent.prefix) > 0 {
-				_, _ = fmt.Fprint(os.Stderr, outputEvent.prefix)
+				_, _ = fmt.Fprint(stderr, outputEvent.prefix)
 			}
 
-			fd := os.Stderr
+			fd := stderr
 			if outputEvent.std {
-				fd = os.Stdout
+				fd = stdout
 			}
 
 			_, _ = fmt.Fprint(fd, line, "\n")
 		}
 	}
+
+	if format == FormatJSON {
+		if records == 0 {
+			_, _ = fmt.Fprint(stdout, "[")
+		}
+
+		_, _ = fmt.Fprint(stdout, "\n]\n")
+	}
+}
+
+func printRecord(stdout io.Writer, content string, index uint64) {
+	if format != FormatJSON {
+		_, _ = fmt.Fprint(stdout, content, "\n")
+		return
+	}
+
+	separator := ",\n"
+	if index == 0 {
+		separator = "[\n"
+	}
+
+	_, _ = fmt.Fprint(stdout, separator, content)
 }
 
 func Close() {
-	close(outputChan)
+	closeMutex.Lock()
+	defer closeMutex.Unlock()
+
+	if !closed {
+		closed = true
+		close(outputChan)
+	}
 }
 
 func Done() <-chan struct{} {
@@ -51,5 +122,34 @@ func Done() <-chan struct{} {
 }
 
 func outputContent(std bool, prefix, message string) {
-	outputChan <- event{std: std, prefix: prefix, message: message}
+	send(event{std: std, prefix: prefix, message: message})
+}
+
+func outputRecord(record map[string]any) {
+	var payload []byte
+	var err error
+
+	if format == FormatJSON {
+		payload, err = json.MarshalIndent(record, "  ", "  ")
+		payload = append([]byte("  "), payload...)
+	} else {
+		payload, err = json.Marshal(record)
+	}
+
+	if err != nil {
+		Err("", "marshal record: %s", err)
+		return
+	}
+
+	send(event{std: true, record: true, message: string(payload)})
+}
+
+// send discards event once closed, an action ignoring its context may still write after its timeout
+func send(outputEvent event) {
+	closeMutex.RLock()
+	defer closeMutex.RUnlock()
+
+	if !closed {
+		outputChan <- outputEvent
+	}
 }
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPrintEvents is not parallel, the format being global
+func TestPrintEvents(t *testing.T) {
+	type args struct {
+		format string
+		events []event
+	}
+
+	cases := map[string]struct {
+		args       args
+		wantStdout string
+		wantStderr string
+	}{
+		"text": {
+			args{
+				events: []event{
+					{std: true, message: "first\nsecond"},
+					{prefix: "[prod] ", message: "warning"},
+				},
+			},
+			"first\nsecond\n",
+			"[prod] warning\n",
+		},
+		"json": {
+			args{
+				format: FormatJSON,
+				events: []event{
+					{record: true, message: `  {"message":"first"}`},
+					{message: "warning"},
+					{record: true, message: `  {"message":"second"}`},
+				},
+			},
+			"[\n  {\"message\":\"first\"},\n  {\"message\":\"second\"}\n]\n",
+			"warning\n",
+		},
+		"json without record": {
+			args{
+				format: FormatJSON,
+			},
+			"[\n]\n",
+			"",
+		},
+		"ndjson": {
+			args{
+				format: FormatNDJSON,
+				events: []event{
+					{record: true, message: `{"message":"first"}`},
+					{record: true, message: `{"message":"second"}`},
+				},
+			},
+			"{\"message\":\"first\"}\n{\"message\":\"second\"}\n",
+			"",
+		},
+		"ndjson without record": {
+			args{
+				format: FormatNDJSON,
+			},
+			"",
+			"",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			previous := format
+			format = testCase.args.format
+
+			defer func() {
+				format = previous
+			}()
+
+			events := make(chan event, len(testCase.args.events))
+			for _, item := range testCase.args.events {
+				events <- item
+			}
+
+			close(events)
+
+			var stdout, stderr strings.Builder
+			printEvents(events, &stdout, &stderr)
+
+			if got := stdout.String(); got != testCase.wantStdout {
+				t.Errorf("printEvents() stdout = %q, want %q", got, testCase.wantStdout)
+			}
+
+			if got := stderr.String(); got != testCase.wantStderr {
+				t.Errorf("printEvents() stderr = %q, want %q", got, testCase.wantStderr)
+			}
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	Close()
+	Close()
+
+	<-Done()
+
+	// discarded once closed, instead of panicking on the closed channel
+	Std("", "after close")
+}
@@ -0,0 +1,257 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ViBiOh/kmux/pkg/client"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+var podTemplatePaths = [][]string{
+	{"spec", "template"},
+	{"spec", "jobTemplate", "spec", "template"},
+}
+
+// ResolveKind finds the resource of the kind through the cached discovery of the context, by plural, singular, short name or group, e.g. `rollouts.argoproj.io`
+func ResolveKind(kube client.Kube, kind string) (*meta.RESTMapping, error) {
+	mapper := restmapper.NewShortcutExpander(kube.Mapper, kube.CachedDiscovery, func(string) {})
+
+	var resource schema.GroupVersionResource
+	var err error
+
+	fullySpecified, groupResource := schema.ParseResourceArg(strings.ToLower(kind))
+	if fullySpecified != nil {
+		resource, _ = mapper.ResourceFor(*fullySpecified)
+	}
+
+	if resource.Empty() {
+		resource, err = mapper.ResourceFor(groupResource.WithVersion(""))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", unhandledError(kind), err)
+		}
+	}
+
+	gvk, err := mapper.KindFor(resource)
+	if err != nil {
+		return nil, fmt.Errorf("find kind of `%s`: %w", resource, err)
+	}
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("find mapping of `%s`: %w", gvk, err)
+	}
+
+	return mapping, nil
+}
+
+func dynamicResource(kube client.Kube, kind, namespace string) (dynamic.ResourceInterface, error) {
+	mapping, err := ResolveKind(kube, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return kube.Dynamic.Resource(mapping.Resource).Namespace(namespace), nil
+	}
+
+	return kube.Dynamic.Resource(mapping.Resource), nil
+}
+
+func getDynamic(ctx context.Context, kube client.Kube, kind, name string) (*unstructured.Unstructured, error) {
+	resource, err := dynamicResource(kube, kind, kube.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource.Get(ctx, name, metav1.GetOptions{})
+}
+
+func listDynamic(ctx context.Context, kube client.Kube, kind, namespace string) ([]string, error) {
+	resource, err := dynamicResource(kube, kind, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := resource.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]string, len(items.Items))
+	for i, item := range items.Items {
+		output[i] = item.GetName()
+	}
+
+	return output, nil
+}
+
+func podTemplateOf(item *unstructured.Unstructured) (v1.PodTemplateSpec, bool, error) {
+	var template v1.PodTemplateSpec
+
+	for _, path := range podTemplatePaths {
+		content, found, err := unstructured.NestedMap(item.Object, path...)
+		if err != nil || !found {
+			continue
+		}
+
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(content, &template); err != nil {
+			return template, false, fmt.Errorf("convert pod template: %w", err)
+		}
+
+		return template, true, nil
+	}
+
+	return template, false, nil
+}
+
+func getDynamicPodSpec(ctx context.Context, kube client.Kube, kind, name string) (v1.PodSpec, error) {
+	item, err := getDynamic(ctx, kube, kind, name)
+	if err != nil {
+		return v1.PodSpec{}, err
+	}
+
+	template, found, err := podTemplateOf(item)
+	if err != nil {
+		return v1.PodSpec{}, err
+	}
+
+	if !found {
+		return v1.PodSpec{}, fmt.Errorf("no pod template found in %s `%s`", item.GetKind(), name)
+	}
+
+	return template.Spec, nil
+}
+
+// getDynamicLabelSelector finds the pod selector from the `spec.selector`, then the scale subresource, then the pod template labels
+func getDynamicLabelSelector(ctx context.Context, kube client.Kube, kind, name string) (*metav1.LabelSelector, error) {
+	item, err := getDynamic(ctx, kube, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if selector, found, _ := unstructured.NestedFieldNoCopy(item.Object, "spec", "selector"); found {
+		return parseDynamicSelector(selector, item.GetKind(), name)
+	}
+
+	if scale, err := getDynamicScale(ctx, kube, kind, name); err == nil && len(scale.Status.Selector) != 0 {
+		return metav1.ParseToLabelSelector(scale.Status.Selector)
+	}
+
+	template, found, err := podTemplateOf(item)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found || len(template.Labels) == 0 {
+		return nil, fmt.Errorf("no pod selector found in %s `%s`", item.GetKind(), name)
+	}
+
+	return &metav1.LabelSelector{MatchLabels: template.Labels}, nil
+}
+
+// parseDynamicSelector parses a `spec.selector` either as a label selector object or as a string, an empty one selecting every pod being an error
+func parseDynamicSelector(selector any, kind, name string) (*metav1.LabelSelector, error) {
+	var labelSelector *metav1.LabelSelector
+
+	switch value := selector.(type) {
+	case map[string]any:
+		_, hasLabels := value["matchLabels"]
+		_, hasExpressions := value["matchExpressions"]
+
+		if !hasLabels && !hasExpressions {
+			return nil, fmt.Errorf("unsupported selector of %s `%s`: neither matchLabels nor matchExpressions", kind, name)
+		}
+
+		labelSelector = &metav1.LabelSelector{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(value, labelSelector); err != nil {
+			return nil, fmt.Errorf("convert selector: %w", err)
+		}
+
+	case string:
+		var err error
+
+		labelSelector, err = metav1.ParseToLabelSelector(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse selector: %w", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported selector of %s `%s`: %T", kind, name, selector)
+	}
+
+	if len(labelSelector.MatchLabels) == 0 && len(labelSelector.MatchExpressions) == 0 {
+		return nil, fmt.Errorf("empty selector of %s `%s` would select every pod", kind, name)
+	}
+
+	return labelSelector, nil
+}
+
+func getDynamicScale(ctx context.Context, kube client.Kube, kind, name string) (*autoscalingv1.Scale, error) {
+	resource, err := dynamicResource(kube, kind, kube.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := resource.Get(ctx, name, metav1.GetOptions{}, "scale")
+	if err != nil {
+		return nil, fmt.Errorf("get scale: %w", err)
+	}
+
+	var scale autoscalingv1.Scale
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &scale); err != nil {
+		return nil, fmt.Errorf("convert scale: %w", err)
+	}
+
+	return &scale, nil
+}
+
+func updateDynamicScale(ctx context.Context, kube client.Kube, kind, name string, scale *autoscalingv1.Scale) error {
+	resource, err := dynamicResource(kube, kind, kube.Namespace)
+	if err != nil {
+		return err
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(scale)
+	if err != nil {
+		return fmt.Errorf("convert scale: %w", err)
+	}
+
+	item := &unstructured.Unstructured{Object: content}
+	item.SetGroupVersionKind(autoscalingv1.SchemeGroupVersion.WithKind("Scale"))
+
+	_, err = resource.Update(ctx, item, metav1.UpdateOptions{}, "scale")
+
+	return err
+}
+
+// patchDynamicPodTemplate applies the merge patch to a resource only if it has a pod template
+func patchDynamicPodTemplate(ctx context.Context, kube client.Kube, kind, name string, payload []byte) error {
+	resource, err := dynamicResource(kube, kind, kube.Namespace)
+	if err != nil {
+		return err
+	}
+
+	item, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, found, _ := unstructured.NestedMap(item.Object, "spec", "template"); !found {
+		return fmt.Errorf("no pod template found in %s", item.GetKind())
+	}
+
+	_, err = resource.Patch(ctx, name, types.MergePatchType, payload, metav1.PatchOptions{})
+
+	return err
+}
@@ -0,0 +1,170 @@
+package resource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ViBiOh/kmux/pkg/client/clienttest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func rollout(name string, selector map[string]any) *unstructured.Unstructured {
+	spec := map[string]any{
+		"template": map[string]any{
+			"metadata": map[string]any{
+				"labels": map[string]any{"app": name, "rollout": "true"},
+			},
+			"spec": map[string]any{
+				"containers": []any{
+					map[string]any{"name": name, "image": name + ":2.0.0"},
+				},
+			},
+		},
+	}
+
+	if selector != nil {
+		spec["selector"] = selector
+	}
+
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata":   map[string]any{"name": name, "namespace": "default"},
+		"spec":       spec,
+	}}
+}
+
+func TestDynamic(t *testing.T) {
+	t.Parallel()
+
+	kube := clienttest.NewContext(clienttest.Context{
+		Name:      "prod",
+		Namespace: "default",
+		Resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "argoproj.io/v1alpha1",
+				APIResources: []metav1.APIResource{
+					{Name: "rollouts", SingularName: "rollout", ShortNames: []string{"ro"}, Kind: "Rollout", Namespaced: true, Verbs: []string{"get", "list"}},
+				},
+			},
+		},
+		DynamicObjects: []runtime.Object{
+			rollout("api", map[string]any{"matchLabels": map[string]any{"app": "api"}}),
+			rollout("worker", nil),
+			rollout("flat", map[string]any{"app": "flat"}),
+			rollout("empty", map[string]any{"matchLabels": map[string]any{}}),
+		},
+	})
+
+	type args struct {
+		kind string
+		name string
+	}
+
+	cases := map[string]struct {
+		args            args
+		wantImage       string
+		wantSelector    string
+		wantErr         bool
+		wantSelectorErr bool
+	}{
+		"plural": {
+			args{
+				kind: "rollouts",
+				name: "api",
+			},
+			"api:2.0.0",
+			"app=api",
+			false,
+			false,
+		},
+		"short name": {
+			args{
+				kind: "ro",
+				name: "api",
+			},
+			"api:2.0.0",
+			"app=api",
+			false,
+			false,
+		},
+		"group": {
+			args{
+				kind: "rollouts.argoproj.io",
+				name: "api",
+			},
+			"api:2.0.0",
+			"app=api",
+			false,
+			false,
+		},
+		"template labels": {
+			args{
+				kind: "rollout",
+				name: "worker",
+			},
+			"worker:2.0.0",
+			"app=worker,rollout=true",
+			false,
+			false,
+		},
+		"unknown kind": {
+			args{
+				kind: "canaries",
+				name: "api",
+			},
+			"",
+			"",
+			true,
+			true,
+		},
+		"flat selector": {
+			args{
+				kind: "rollouts",
+				name: "flat",
+			},
+			"flat:2.0.0",
+			"",
+			false,
+			true,
+		},
+		"empty selector": {
+			args{
+				kind: "rollouts",
+				name: "empty",
+			},
+			"empty:2.0.0",
+			"",
+			false,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			podSpec, err := GetPodSpec(context.Background(), kube, testCase.args.kind, testCase.args.name)
+			if (err != nil) != testCase.wantErr {
+				t.Errorf("GetPodSpec() = `%v`, want error %t", err, testCase.wantErr)
+				return
+			}
+
+			if err == nil && podSpec.Containers[0].Image != testCase.wantImage {
+				t.Errorf("GetPodSpec() = `%s`, want `%s`", podSpec.Containers[0].Image, testCase.wantImage)
+			}
+
+			_, options, _, err := GetPodsSelector(context.Background(), kube, testCase.args.kind, testCase.args.name)
+			if (err != nil) != testCase.wantSelectorErr {
+				t.Errorf("GetPodsSelector() = `%v`, want error %t", err, testCase.wantSelectorErr)
+				return
+			}
+
+			if options.LabelSelector != testCase.wantSelector {
+				t.Errorf("GetPodsSelector() = `%s`, want `%s`", options.LabelSelector, testCase.wantSelector)
+			}
+		})
+	}
+}
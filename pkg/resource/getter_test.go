@@ -0,0 +1,208 @@
+package resource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ViBiOh/kmux/pkg/client"
+	"github.com/ViBiOh/kmux/pkg/client/clienttest"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func deployment(namespace, name, image string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{Containers: []v1.Container{{Name: name, Image: image}}},
+			},
+		},
+	}
+}
+
+func job(name, owner string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            name,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: owner, UID: types.UID(owner)}},
+		},
+	}
+}
+
+func TestGetPodSpec(t *testing.T) {
+	t.Parallel()
+
+	clients := clienttest.NewArray(
+		clienttest.Context{Name: "prod-eu", Namespace: "default", Objects: []runtime.Object{deployment("default", "api", "api:1.0.0")}},
+		clienttest.Context{Name: "prod-us", Namespace: "default", Objects: []runtime.Object{deployment("default", "api", "api:1.1.0")}},
+		clienttest.Context{Name: "staging", Namespace: "default"},
+	)
+
+	type args struct {
+		kind string
+		name string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    map[string]string
+		wantErr map[string]bool
+	}{
+		"deployment": {
+			args{
+				kind: "deploy",
+				name: "api",
+			},
+			map[string]string{"prod-eu": "api:1.0.0", "prod-us": "api:1.1.0"},
+			map[string]bool{"staging": true},
+		},
+		"unhandled": {
+			args{
+				kind: "unknown",
+				name: "api",
+			},
+			map[string]string{},
+			map[string]bool{"prod-eu": true, "prod-us": true, "staging": true},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			for _, kube := range clients.Kubes() {
+				podSpec, err := GetPodSpec(context.Background(), kube, testCase.args.kind, testCase.args.name)
+
+				if gotErr := err != nil; gotErr != testCase.wantErr[kube.Name] {
+					t.Errorf("GetPodSpec() on `%s` = %v, want error %t", kube.Name, err, testCase.wantErr[kube.Name])
+					continue
+				}
+
+				if err != nil {
+					continue
+				}
+
+				if got := podSpec.Containers[0].Image; got != testCase.want[kube.Name] {
+					t.Errorf("GetPodSpec() on `%s` = `%s`, want `%s`", kube.Name, got, testCase.want[kube.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestListPods(t *testing.T) {
+	t.Parallel()
+
+	pod := func(name string, labels map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+	}
+
+	worker := deployment("default", "worker", "worker:1.0.0")
+	worker.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "worker"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"batch", "stream"}},
+			{Key: "canary", Operator: metav1.LabelSelectorOpDoesNotExist},
+		},
+	}
+
+	kube := clienttest.New("prod", "default",
+		deployment("default", "api", "api:1.0.0"),
+		worker,
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "front"},
+			Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "front"}},
+		},
+		pod("api-1", map[string]string{"app": "api"}),
+		pod("api-2", map[string]string{"app": "api"}),
+		pod("front-1", map[string]string{"app": "front"}),
+		pod("worker-1", map[string]string{"app": "worker", "tier": "batch"}),
+		pod("worker-2", map[string]string{"app": "worker", "tier": "stream"}),
+		pod("worker-3", map[string]string{"app": "worker", "tier": "web"}),
+		pod("worker-4", map[string]string{"app": "worker", "tier": "batch", "canary": "true"}),
+		&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "backup", UID: "backup"}},
+		job("backup-1", "backup"),
+		job("backup-2", "backup"),
+		job("purge-1", "purge"),
+		pod("backup-1-abcde", map[string]string{"job-name": "backup-1"}),
+		pod("backup-2-abcde", map[string]string{"job-name": "backup-2"}),
+		pod("purge-1-abcde", map[string]string{"job-name": "purge-1"}),
+	)
+
+	type args struct {
+		kube client.Kube
+		kind string
+		name string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    int
+		wantErr bool
+	}{
+		"deployment": {
+			args{
+				kube: kube,
+				kind: "deployments",
+				name: "api",
+			},
+			2,
+			false,
+		},
+		"match expressions": {
+			args{
+				kube: kube,
+				kind: "deploy",
+				name: "worker",
+			},
+			2,
+			false,
+		},
+		"cronjob": {
+			args{
+				kube: kube,
+				kind: "cj",
+				name: "backup",
+			},
+			2,
+			false,
+		},
+		"service": {
+			args{
+				kube: kube,
+				kind: "svc",
+				name: "front",
+			},
+			1,
+			false,
+		},
+		"not found": {
+			args{
+				kube: kube,
+				kind: "deployments",
+				name: "front",
+			},
+			0,
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := ListPods(context.Background(), testCase.args.kube, testCase.args.kind, testCase.args.name)
+
+			if (gotErr != nil) != testCase.wantErr || len(got) != testCase.want {
+				t.Errorf("ListPods() = (%d, `%v`), want (%d, error %t)", len(got), gotErr, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -6,151 +6,23 @@ import (
 
 	"github.com/ViBiOh/kmux/pkg/client"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Lister func(context.Context, client.Kube, string) ([]string, error)
 
 func ListerFor(kind string) (Lister, error) {
-	switch kind {
-	case "cj", "cronjob", "cronjobs":
+	item, ok := Lookup(kind)
+	if !ok {
 		return func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
-			items, err := kube.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "ds", "daemonset", "daemonsets":
-		return func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
-			items, err := kube.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "deploy", "deployment", "deployments":
-		return func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
-			items, err := kube.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "job", "jobs":
-		return func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
-			items, err := kube.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "po", "pod", "pods":
-		return func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
-			items, err := kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "ns", "namespace", "namespaces":
-		return func(ctx context.Context, kube client.Kube, _ string) ([]string, error) {
-			items, err := kube.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "no", "node", "nodes":
-		return func(ctx context.Context, kube client.Kube, _ string) ([]string, error) {
-			items, err := kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "svc", "service", "services":
-		return func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
-			items, err := kube.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
-		}, nil
-
-	case "sts", "statefulset", "statefulsets":
-		return func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
-			items, err := kube.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-
-			output := make([]string, len(items.Items))
-			for i, item := range items.Items {
-				output[i] = item.GetName()
-			}
-
-			return output, nil
+			return listDynamic(ctx, kube, kind, namespace)
 		}, nil
+	}
 
-	default:
-		return nil, fmt.Errorf("unhandled resource type `%s`", kind)
+	if !item.Has(Listable) {
+		return nil, capabilityError(item, Listable)
 	}
+
+	return item.List, nil
 }
 
 func ListPods(ctx context.Context, kube client.Kube, kind, name string) ([]v1.Pod, error) {
@@ -0,0 +1,676 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ViBiOh/kmux/pkg/client"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+type Capability uint
+
+const (
+	Listable Capability = 1 << iota
+	PodTemplate
+	PodSelector
+	Scalable
+	Restartable
+	Forwardable
+)
+
+func (c Capability) String() string {
+	switch c {
+	case Listable:
+		return "listable"
+	case PodTemplate:
+		return "pod template"
+	case PodSelector:
+		return "pod selector"
+	case Scalable:
+		return "scalable"
+	case Restartable:
+		return "restartable"
+	case Forwardable:
+		return "forwardable"
+	default:
+		return "unknown"
+	}
+}
+
+type (
+	PodSpecGetter      func(context.Context, client.Kube, string) (v1.PodSpec, error)
+	PodsSelectorGetter func(context.Context, client.Kube, string) (string, metav1.ListOptions, PodFilter, error)
+	ScaleGetter        func(context.Context, client.Kube, string) (*autoscalingv1.Scale, error)
+	ScaleUpdater       func(context.Context, client.Kube, string, *autoscalingv1.Scale) error
+	Restarter          func(context.Context, client.Kube, string, []byte) error
+)
+
+// Kind describes a resource type handled natively, its capabilities are given by the defined functions
+type Kind struct {
+	List         Lister
+	PodSpec      PodSpecGetter
+	PodsSelector PodsSelectorGetter
+	// WatchPodsSelector also selects the pods created while watching, PodsSelector being used when not defined
+	WatchPodsSelector PodsSelectorGetter
+	GetScale          ScaleGetter
+	UpdateScale       ScaleUpdater
+	Restart           Restarter
+	Name              string
+	Aliases           []string
+	Forwardable       bool
+}
+
+func (k Kind) Has(capability Capability) bool {
+	switch capability {
+	case Listable:
+		return k.List != nil
+	case PodTemplate:
+		return k.PodSpec != nil
+	case PodSelector:
+		return k.PodsSelector != nil
+	case Scalable:
+		return k.GetScale != nil && k.UpdateScale != nil
+	case Restartable:
+		return k.Restart != nil
+	case Forwardable:
+		return k.Forwardable && k.PodsSelector != nil
+	default:
+		return false
+	}
+}
+
+var kinds = []Kind{
+	{
+		Name:    "cronjobs",
+		Aliases: []string{"cj", "cronjob"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodSpec: func(ctx context.Context, kube client.Kube, name string) (v1.PodSpec, error) {
+			item, err := kube.BatchV1().CronJobs(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return v1.PodSpec{}, err
+			}
+
+			return item.Spec.JobTemplate.Spec.Template.Spec, nil
+		},
+		PodsSelector:      cronJobPodsSelector,
+		WatchPodsSelector: cronJobWatchPodsSelector,
+	},
+	{
+		Name:    "daemonsets",
+		Aliases: []string{"ds", "daemonset"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodSpec: func(ctx context.Context, kube client.Kube, name string) (v1.PodSpec, error) {
+			item, err := kube.AppsV1().DaemonSets(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return v1.PodSpec{}, err
+			}
+
+			return item.Spec.Template.Spec, nil
+		},
+		PodsSelector: labelPodsSelector(func(ctx context.Context, kube client.Kube, name string) (*metav1.LabelSelector, error) {
+			item, err := kube.AppsV1().DaemonSets(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return item.Spec.Selector, nil
+		}),
+		Restart: func(ctx context.Context, kube client.Kube, name string, payload []byte) error {
+			_, err := kube.AppsV1().DaemonSets(kube.Namespace).Patch(ctx, name, types.MergePatchType, payload, metav1.PatchOptions{})
+			return err
+		},
+		Forwardable: true,
+	},
+	{
+		Name:    "deployments",
+		Aliases: []string{"deploy", "deployment"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodSpec: func(ctx context.Context, kube client.Kube, name string) (v1.PodSpec, error) {
+			item, err := kube.AppsV1().Deployments(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return v1.PodSpec{}, err
+			}
+
+			return item.Spec.Template.Spec, nil
+		},
+		PodsSelector: labelPodsSelector(func(ctx context.Context, kube client.Kube, name string) (*metav1.LabelSelector, error) {
+			item, err := kube.AppsV1().Deployments(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return item.Spec.Selector, nil
+		}),
+		GetScale: func(ctx context.Context, kube client.Kube, name string) (*autoscalingv1.Scale, error) {
+			return kube.AppsV1().Deployments(kube.Namespace).GetScale(ctx, name, metav1.GetOptions{})
+		},
+		UpdateScale: func(ctx context.Context, kube client.Kube, name string, scale *autoscalingv1.Scale) error {
+			_, err := kube.AppsV1().Deployments(kube.Namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+			return err
+		},
+		Restart: func(ctx context.Context, kube client.Kube, name string, payload []byte) error {
+			_, err := kube.AppsV1().Deployments(kube.Namespace).Patch(ctx, name, types.MergePatchType, payload, metav1.PatchOptions{})
+			return err
+		},
+		Forwardable: true,
+	},
+	{
+		Name:    "jobs",
+		Aliases: []string{"job"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodSpec: func(ctx context.Context, kube client.Kube, name string) (v1.PodSpec, error) {
+			item, err := kube.BatchV1().Jobs(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return v1.PodSpec{}, err
+			}
+
+			return item.Spec.Template.Spec, nil
+		},
+		PodsSelector: labelPodsSelector(func(ctx context.Context, kube client.Kube, name string) (*metav1.LabelSelector, error) {
+			item, err := kube.BatchV1().Jobs(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return item.Spec.Selector, nil
+		}),
+		Restart: replaceJob,
+	},
+	{
+		Name:    "namespaces",
+		Aliases: []string{"ns", "namespace"},
+		List: func(ctx context.Context, kube client.Kube, _ string) ([]string, error) {
+			items, err := kube.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodsSelector: func(_ context.Context, kube client.Kube, name string) (string, metav1.ListOptions, PodFilter, error) {
+			if len(name) == 0 {
+				return kube.Namespace, metav1.ListOptions{}, nil, nil
+			}
+
+			return name, metav1.ListOptions{}, nil, nil
+		},
+	},
+	{
+		Name:    "nodes",
+		Aliases: []string{"no", "node"},
+		List: func(ctx context.Context, kube client.Kube, _ string) ([]string, error) {
+			items, err := kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodsSelector: fieldPodsSelector("spec.nodeName"),
+	},
+	{
+		Name:    "pods",
+		Aliases: []string{"po", "pod"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodSpec: func(ctx context.Context, kube client.Kube, name string) (v1.PodSpec, error) {
+			item, err := kube.CoreV1().Pods(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return v1.PodSpec{}, err
+			}
+
+			return item.Spec, nil
+		},
+		PodsSelector: fieldPodsSelector("metadata.name"),
+		Forwardable:  true,
+	},
+	{
+		Name:    "replicasets",
+		Aliases: []string{"rs", "replicaset"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodSpec: func(ctx context.Context, kube client.Kube, name string) (v1.PodSpec, error) {
+			item, err := kube.AppsV1().ReplicaSets(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return v1.PodSpec{}, err
+			}
+
+			return item.Spec.Template.Spec, nil
+		},
+		PodsSelector: labelPodsSelector(func(ctx context.Context, kube client.Kube, name string) (*metav1.LabelSelector, error) {
+			item, err := kube.AppsV1().ReplicaSets(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return item.Spec.Selector, nil
+		}),
+		GetScale: func(ctx context.Context, kube client.Kube, name string) (*autoscalingv1.Scale, error) {
+			return kube.AppsV1().ReplicaSets(kube.Namespace).GetScale(ctx, name, metav1.GetOptions{})
+		},
+		UpdateScale: func(ctx context.Context, kube client.Kube, name string, scale *autoscalingv1.Scale) error {
+			_, err := kube.AppsV1().ReplicaSets(kube.Namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+			return err
+		},
+	},
+	{
+		Name:    "services",
+		Aliases: []string{"svc", "service"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodsSelector: func(ctx context.Context, kube client.Kube, name string) (string, metav1.ListOptions, PodFilter, error) {
+			service, err := kube.CoreV1().Services(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return "", metav1.ListOptions{}, nil, fmt.Errorf("get services: %w", err)
+			}
+
+			return kube.Namespace, metav1.ListOptions{LabelSelector: labelSelectorFromMaps(service.Spec.Selector)}, nil, nil
+		},
+		Forwardable: true,
+	},
+	{
+		Name:    "statefulsets",
+		Aliases: []string{"sts", "statefulset"},
+		List: func(ctx context.Context, kube client.Kube, namespace string) ([]string, error) {
+			items, err := kube.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return namesOf(items.Items), nil
+		},
+		PodSpec: func(ctx context.Context, kube client.Kube, name string) (v1.PodSpec, error) {
+			item, err := kube.AppsV1().StatefulSets(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return v1.PodSpec{}, err
+			}
+
+			return item.Spec.Template.Spec, nil
+		},
+		PodsSelector: labelPodsSelector(func(ctx context.Context, kube client.Kube, name string) (*metav1.LabelSelector, error) {
+			item, err := kube.AppsV1().StatefulSets(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			return item.Spec.Selector, nil
+		}),
+		GetScale: func(ctx context.Context, kube client.Kube, name string) (*autoscalingv1.Scale, error) {
+			return kube.AppsV1().StatefulSets(kube.Namespace).GetScale(ctx, name, metav1.GetOptions{})
+		},
+		UpdateScale: func(ctx context.Context, kube client.Kube, name string, scale *autoscalingv1.Scale) error {
+			_, err := kube.AppsV1().StatefulSets(kube.Namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+			return err
+		},
+		Restart: func(ctx context.Context, kube client.Kube, name string, payload []byte) error {
+			_, err := kube.AppsV1().StatefulSets(kube.Namespace).Patch(ctx, name, types.MergePatchType, payload, metav1.PatchOptions{})
+			return err
+		},
+		Forwardable: true,
+	},
+}
+
+// Lookup finds the natively handled kind by its name or one of its aliases
+func Lookup(kind string) (Kind, bool) {
+	for _, item := range kinds {
+		if item.Name == kind || slices.Contains(item.Aliases, kind) {
+			return item, true
+		}
+	}
+
+	return Kind{}, false
+}
+
+// IsKind checks if the given kind is the named one or one of its aliases
+func IsKind(kind, name string) bool {
+	item, ok := Lookup(kind)
+
+	return ok && item.Name == name
+}
+
+// Names lists natively handled kinds having the capability, e.g. for completion
+func Names(capability Capability) []string {
+	var output []string
+
+	for _, item := range kinds {
+		if item.Has(capability) {
+			output = append(output, item.Name)
+		}
+	}
+
+	return output
+}
+
+func capabilityError(kind Kind, capability Capability) error {
+	return fmt.Errorf("resource type `%s` has no %s capability, supported types are: %s", kind.Name, capability, strings.Join(Names(capability), ", "))
+}
+
+func namesOf[T any, PT interface {
+	*T
+	GetName() string
+}](items []T) []string {
+	output := make([]string, len(items))
+	for i := range items {
+		output[i] = PT(&items[i]).GetName()
+	}
+
+	return output
+}
+
+func labelPodsSelector(getter func(context.Context, client.Kube, string) (*metav1.LabelSelector, error)) PodsSelectorGetter {
+	return func(ctx context.Context, kube client.Kube, name string) (string, metav1.ListOptions, PodFilter, error) {
+		labelSelector, err := getter(ctx, kube, name)
+		if err != nil {
+			return "", metav1.ListOptions{}, nil, err
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			return "", metav1.ListOptions{}, nil, fmt.Errorf("convert label selector: %w", err)
+		}
+
+		return kube.Namespace, metav1.ListOptions{LabelSelector: selector.String()}, nil, nil
+	}
+}
+
+func fieldPodsSelector(field string) PodsSelectorGetter {
+	return func(_ context.Context, kube client.Kube, name string) (string, metav1.ListOptions, PodFilter, error) {
+		return kube.Namespace, metav1.ListOptions{FieldSelector: fmt.Sprintf("%s=%s", field, name)}, nil, nil
+	}
+}
+
+// cronJobPodsSelector selects the pods of the jobs owned by the cronjob when listing
+func cronJobPodsSelector(ctx context.Context, kube client.Kube, name string) (string, metav1.ListOptions, PodFilter, error) {
+	cronjob, ownership, _, err := getJobOwnership(ctx, kube, name)
+	if err != nil {
+		return "", metav1.ListOptions{}, nil, err
+	}
+
+	owned := ownership.names()
+	if len(owned) == 0 {
+		// an `in` requirement needs values, the pods of jobs being all rejected instead
+		return jobPodsSelector(kube, cronjob, func(context.Context, client.Kube, v1.Pod) bool {
+			return false
+		})
+	}
+
+	jobCreated, err := labels.NewRequirement(jobNameLabel, selection.In, owned)
+	if err != nil {
+		return "", metav1.ListOptions{}, nil, fmt.Errorf("create job requirement: %w", err)
+	}
+
+	selector := labels.SelectorFromSet(cronjob.Spec.JobTemplate.Spec.Template.Labels).Add(*jobCreated)
+
+	return kube.Namespace, metav1.ListOptions{LabelSelector: selector.String()}, nil, nil
+}
+
+// cronJobWatchPodsSelector selects the pods of every job and filters the ones owned by the cronjob, the jobs created later being watched until the context is done
+func cronJobWatchPodsSelector(ctx context.Context, kube client.Kube, name string) (string, metav1.ListOptions, PodFilter, error) {
+	cronjob, ownership, resourceVersion, err := getJobOwnership(ctx, kube, name)
+	if err != nil {
+		return "", metav1.ListOptions{}, nil, err
+	}
+
+	go ownership.watch(ctx, kube, cronjob.Namespace, resourceVersion)
+
+	return jobPodsSelector(kube, cronjob, func(ctx context.Context, kube client.Kube, pod v1.Pod) bool {
+		jobName := pod.Labels[jobNameLabel]
+		if len(jobName) == 0 {
+			return false
+		}
+
+		return ownership.owns(ctx, kube, cronjob.Namespace, jobName)
+	})
+}
+
+// jobPodsSelector selects the pods of every job created from the cronjob's template, filtered by the given one
+func jobPodsSelector(kube client.Kube, cronjob *batchv1.CronJob, postListFilter PodFilter) (string, metav1.ListOptions, PodFilter, error) {
+	jobCreated, err := labels.NewRequirement(jobNameLabel, selection.Exists, nil)
+	if err != nil {
+		return "", metav1.ListOptions{}, nil, fmt.Errorf("create job requirement: %w", err)
+	}
+
+	selector := labels.SelectorFromSet(cronjob.Spec.JobTemplate.Spec.Template.Labels).Add(*jobCreated)
+
+	return kube.Namespace, metav1.ListOptions{LabelSelector: selector.String()}, postListFilter, nil
+}
+
+// getJobOwnership gets the cronjob and the ownership of the jobs of its namespace, with the resource version of their listing
+func getJobOwnership(ctx context.Context, kube client.Kube, name string) (*batchv1.CronJob, *jobOwnership, string, error) {
+	cronjob, err := kube.BatchV1().CronJobs(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("get cronjob: %w", err)
+	}
+
+	jobs, err := kube.BatchV1().Jobs(cronjob.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("list jobs: %w", err)
+	}
+
+	ownership := &jobOwnership{
+		owner: cronjob.UID,
+		owned: make(map[string]bool, len(jobs.Items)),
+	}
+
+	for _, job := range jobs.Items {
+		ownership.owned[job.Name] = ownership.isOwner(job)
+	}
+
+	return cronjob, ownership, jobs.ResourceVersion, nil
+}
+
+const jobNameLabel = "job-name"
+
+// jobOwnership caches which jobs are owned by a cronjob, kept up to date by watching the jobs of its namespace.
+// A job not seen yet by the watch is fetched once, its ownership being cached even when it's not found.
+type jobOwnership struct {
+	owned      map[string]bool
+	owner      types.UID
+	generation uint64 // changes of the watch, for not caching a fetched ownership outdated meanwhile
+	mutex      sync.Mutex
+}
+
+func (jo *jobOwnership) owns(ctx context.Context, kube client.Kube, namespace, name string) bool {
+	jo.mutex.Lock()
+	owned, ok := jo.owned[name]
+	generation := jo.generation
+	jo.mutex.Unlock()
+
+	if ok {
+		return owned
+	}
+
+	job, err := kube.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		owned = false
+
+	case err != nil:
+		kube.Warn("get job `%s`: %s", name, err)
+
+		return false
+
+	default:
+		owned = jo.isOwner(*job)
+	}
+
+	jo.mutex.Lock()
+	defer jo.mutex.Unlock()
+
+	// the watch is the most recent, the fetched ownership is only cached when nothing was watched meanwhile
+	if current, ok := jo.owned[name]; ok {
+		return current
+	}
+
+	if jo.generation == generation {
+		jo.owned[name] = owned
+	}
+
+	return owned
+}
+
+// names returns the sorted names of the owned jobs
+func (jo *jobOwnership) names() []string {
+	jo.mutex.Lock()
+	defer jo.mutex.Unlock()
+
+	var names []string
+
+	for name, owned := range jo.owned {
+		if owned {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+func (jo *jobOwnership) set(name string, owned bool) {
+	jo.mutex.Lock()
+	defer jo.mutex.Unlock()
+
+	jo.generation++
+	jo.owned[name] = owned
+}
+
+func (jo *jobOwnership) remove(name string) {
+	jo.mutex.Lock()
+	defer jo.mutex.Unlock()
+
+	jo.generation++
+	delete(jo.owned, name)
+}
+
+func (jo *jobOwnership) watch(ctx context.Context, kube client.Kube, namespace, resourceVersion string) {
+	jobs := kube.BatchV1().Jobs(namespace)
+
+	watcher, err := watchtools.NewRetryWatcherWithContext(ctx, resourceVersion, &cache.ListWatch{
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return jobs.Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		kube.Warn("watch jobs: %s", err)
+		return
+	}
+
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+
+			job, ok := event.Object.(*batchv1.Job)
+			if !ok {
+				continue
+			}
+
+			if event.Type == watch.Deleted {
+				jo.remove(job.Name)
+
+				continue
+			}
+
+			jo.set(job.Name, jo.isOwner(*job))
+		}
+	}
+}
+
+func (jo *jobOwnership) isOwner(job batchv1.Job) bool {
+	for _, reference := range job.OwnerReferences {
+		if reference.UID == jo.owner {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replaceJob restarts a job by deleting then creating it, without its generated selector
+func replaceJob(ctx context.Context, kube client.Kube, name string, _ []byte) error {
+	job, err := kube.BatchV1().Jobs(kube.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	job.Spec.Selector = nil
+	job.Spec.Template.Labels = nil
+
+	if err = kube.BatchV1().Jobs(kube.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+
+	job.ResourceVersion = ""
+
+	// once deleted, the job is created again even if the context is cancelled meanwhile, e.g. on timeout
+	_, err = kube.BatchV1().Jobs(kube.Namespace).Create(context.WithoutCancel(ctx), job, metav1.CreateOptions{})
+	return err
+}
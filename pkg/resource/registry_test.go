@@ -0,0 +1,325 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/client/clienttest"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		kind string
+	}
+
+	cases := map[string]struct {
+		args   args
+		want   string
+		wantOk bool
+	}{
+		"name": {
+			args{
+				kind: "deployments",
+			},
+			"deployments",
+			true,
+		},
+		"alias": {
+			args{
+				kind: "sts",
+			},
+			"statefulsets",
+			true,
+		},
+		"unknown": {
+			args{
+				kind: "rollouts",
+			},
+			"",
+			false,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotOk := Lookup(testCase.args.kind)
+			if got.Name != testCase.want || gotOk != testCase.wantOk {
+				t.Errorf("Lookup() = (`%s`, %t), want (`%s`, %t)", got.Name, gotOk, testCase.want, testCase.wantOk)
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		capability Capability
+	}
+
+	cases := map[string]struct {
+		args args
+		want []string
+	}{
+		"scalable": {
+			args{
+				capability: Scalable,
+			},
+			[]string{"deployments", "replicasets", "statefulsets"},
+		},
+		"restartable": {
+			args{
+				capability: Restartable,
+			},
+			[]string{"daemonsets", "deployments", "jobs", "statefulsets"},
+		},
+		"forwardable": {
+			args{
+				capability: Forwardable,
+			},
+			[]string{"daemonsets", "deployments", "pods", "services", "statefulsets"},
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Names(testCase.args.capability); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Names() = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func cronJob() *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "backup", UID: "backup"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "backup"}}},
+				},
+			},
+		},
+	}
+}
+
+func jobPod(jobName string) v1.Pod {
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: jobName + "-abcde", Labels: map[string]string{"job-name": jobName}}}
+}
+
+func TestCronJobPodsSelector(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		objects []runtime.Object
+	}
+
+	cases := map[string]struct {
+		args       args
+		want       string
+		wantFilter bool
+	}{
+		"owned jobs": {
+			args{
+				objects: []runtime.Object{cronJob(), job("backup-2", "backup"), job("backup-1", "backup"), job("purge-1", "purge")},
+			},
+			"app=backup,job-name in (backup-1,backup-2)",
+			false,
+		},
+		"no job": {
+			args{
+				objects: []runtime.Object{cronJob(), job("purge-1", "purge")},
+			},
+			"app=backup,job-name",
+			true,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			kube := clienttest.New("prod", "default", testCase.args.objects...)
+
+			_, options, filter, err := cronJobPodsSelector(context.Background(), kube, "backup")
+			if err != nil {
+				t.Fatalf("cronJobPodsSelector() = %s", err)
+			}
+
+			if options.LabelSelector != testCase.want || (filter != nil) != testCase.wantFilter {
+				t.Errorf("cronJobPodsSelector() = (`%s`, filter %t), want (`%s`, filter %t)", options.LabelSelector, filter != nil, testCase.want, testCase.wantFilter)
+			}
+
+			if filter != nil && filter(context.Background(), kube, jobPod("purge-1")) {
+				t.Error("filter(purge-1) = true, want false")
+			}
+
+			// the listing of the pods is one-shot, the jobs are not watched
+			if slices.ContainsFunc(clienttest.Fake(kube).Actions(), func(action k8stesting.Action) bool {
+				return action.GetVerb() == "watch"
+			}) {
+				t.Error("cronJobPodsSelector() watched the jobs, want them only listed")
+			}
+		})
+	}
+}
+
+func TestCronJobWatchPodsSelector(t *testing.T) {
+	t.Parallel()
+
+	kube := clienttest.New("prod", "default", cronJob(), job("backup-1", "backup"))
+	// jobs are only known from the list and the watch, a get of a missing one being counted
+	var gets atomic.Int32
+	clienttest.Fake(kube).PrependReactor("get", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gets.Add(1)
+
+		if action.(k8stesting.GetAction).GetName() == "missing" {
+			return true, nil, apierrors.NewNotFound(batchv1.Resource("jobs"), "missing")
+		}
+
+		return true, nil, errors.New("unexpected get")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, options, filter, err := cronJobWatchPodsSelector(ctx, kube, "backup")
+	if err != nil {
+		t.Fatalf("cronJobWatchPodsSelector() = %s", err)
+	}
+
+	if want := "app=backup,job-name"; options.LabelSelector != want {
+		t.Errorf("cronJobWatchPodsSelector() = `%s`, want `%s`", options.LabelSelector, want)
+	}
+
+	if !filter(ctx, kube, jobPod("backup-1")) {
+		t.Error("filter(backup-1) = false, want true")
+	}
+
+	// the fake clientset only notifies the watches started before a creation
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.ContainsFunc(clienttest.Fake(kube).Actions(), func(action k8stesting.Action) bool {
+		return action.GetVerb() == "watch" && action.GetResource().Resource == "jobs"
+	}) {
+		if time.Now().After(deadline) {
+			t.Fatal("jobs not watched")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for index, item := range []*batchv1.Job{job("backup-2", "backup"), job("purge-1", "purge")} {
+		item.ResourceVersion = strconv.Itoa(10 + index) // not set by the fake clientset, but required by the watch
+
+		if _, err := kube.BatchV1().Jobs("default").Create(ctx, item, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("create job: %s", err)
+		}
+	}
+
+	deadline = time.Now().Add(5 * time.Second)
+	for !filter(ctx, kube, jobPod("backup-2")) {
+		if time.Now().After(deadline) {
+			t.Fatal("filter(backup-2) = false, want true once watched")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if filter(ctx, kube, jobPod("purge-1")) {
+		t.Error("filter(purge-1) = true, want false")
+	}
+
+	gets.Store(0)
+
+	for range 2 {
+		if filter(ctx, kube, jobPod("missing")) {
+			t.Error("filter(missing) = true, want false")
+		}
+	}
+
+	if got := gets.Load(); got != 1 {
+		t.Errorf("filter(missing) got the job %d times, want 1", got)
+	}
+}
+
+func TestJobOwnershipOwns(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		watched func(*jobOwnership)
+		want    bool
+		// wantGets is the number of gets of the job for two calls, the ownership being cached when not watched meanwhile
+		wantGets int32
+	}{
+		"fetched": {
+			func(*jobOwnership) {},
+			true,
+			1,
+		},
+		"watched meanwhile": {
+			func(ownership *jobOwnership) {
+				ownership.set("backup-1", false)
+			},
+			false,
+			1,
+		},
+		"deleted meanwhile": {
+			func(ownership *jobOwnership) {
+				ownership.remove("backup-1")
+			},
+			true,
+			2,
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			kube := clienttest.New("prod", "default", job("backup-1", "backup"))
+
+			ownership := &jobOwnership{
+				owner: "backup",
+				owned: make(map[string]bool),
+			}
+
+			// the watch changes the ownership while the job is fetched
+			var gets atomic.Int32
+			clienttest.Fake(kube).PrependReactor("get", "jobs", func(k8stesting.Action) (bool, runtime.Object, error) {
+				if gets.Add(1) == 1 {
+					testCase.watched(ownership)
+				}
+
+				return false, nil, nil
+			})
+
+			for range 2 {
+				if got := ownership.owns(context.Background(), kube, "default", "backup-1"); got != testCase.want {
+					t.Errorf("owns() = %t, want %t", got, testCase.want)
+				}
+			}
+
+			if got := gets.Load(); got != testCase.wantGets {
+				t.Errorf("owns() got the job %d times, want %d", got, testCase.wantGets)
+			}
+		})
+	}
+}
@@ -7,12 +7,7 @@ import (
 )
 
 func IsService(name string) bool {
-	switch name {
-	case "svc", "service", "services":
-		return true
-	default:
-		return false
-	}
+	return IsKind(name, "services")
 }
 
 func IsContainedSelected(container v1.Container, filter *regexp.Regexp) bool {
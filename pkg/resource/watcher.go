@@ -4,11 +4,24 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"time"
 
 	"github.com/ViBiOh/kmux/pkg/client"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	watchMinBackoff = time.Second
+	watchMaxBackoff = 30 * time.Second
+
+	// resumeReportInterval bounds how often the resumes of a watch closed by the server are reported
+	resumeReportInterval = time.Minute
 )
 
 type WrappedWatcher struct {
@@ -26,7 +39,7 @@ func (dw WrappedWatcher) ResultChan() <-chan watch.Event {
 	return dw.pods
 }
 
-func WatchPods(ctx context.Context, kube client.Kube, kind, name string, labelSelector map[string]string, dryRun bool) (watch.Interface, error) {
+func WatchPods(ctx context.Context, kube client.Kube, kind, name, labelSelector string, dryRun bool) (watch.Interface, error) {
 	var listOptions metav1.ListOptions
 	var postListFilter PodFilter
 	var err error
@@ -34,14 +47,18 @@ func WatchPods(ctx context.Context, kube client.Kube, kind, name string, labelSe
 	namespace := kube.Namespace
 
 	if len(kind) > 0 && len(name) > 0 {
-		namespace, listOptions, postListFilter, err = GetPodsSelector(ctx, kube, kind, name)
+		selector := watchPodsSelector
+		if dryRun {
+			selector = GetPodsSelector
+		}
+
+		namespace, listOptions, postListFilter, err = selector(ctx, kube, kind, name)
 		if err != nil {
 			return nil, fmt.Errorf("get list options: %w", err)
 		}
 	}
 
 	if len(labelSelector) > 0 {
-		labelSelector := labelSelectorFromMaps(labelSelector)
 		if len(listOptions.LabelSelector) > 0 {
 			listOptions.LabelSelector += ","
 		}
@@ -53,42 +70,269 @@ func WatchPods(ctx context.Context, kube client.Kube, kind, name string, labelSe
 		return watchPodsDry(ctx, kube, namespace, listOptions, postListFilter)
 	}
 
-	listOptions.Watch = true
-
 	return watchPods(ctx, kube, namespace, listOptions, postListFilter)
 }
 
+// watchPods lists then watches the pods, transparently resuming the watch from the last seen resource version
+// when the server closes it, and relisting when that version is gone.
 func watchPods(ctx context.Context, kube client.Kube, namespace string, options metav1.ListOptions, postListFilter PodFilter) (watch.Interface, error) {
-	watcher, err := kube.CoreV1().Pods(namespace).Watch(ctx, options)
+	ctx, cancel := context.WithCancel(ctx)
+
+	watcher := &podsWatcher{
+		kube:    kube,
+		pods:    kube.CoreV1().Pods(namespace),
+		options: options,
+		filter:  postListFilter,
+		known:   make(map[types.UID]*v1.Pod),
+		output:  make(chan watch.Event, runtime.NumCPU()),
+	}
+
+	initial, err := watcher.list(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("watch: %w", err)
+		cancel()
+
+		return nil, fmt.Errorf("list: %w", err)
 	}
 
-	if postListFilter == nil {
-		return watcher, nil
+	go watcher.run(ctx, initial)
+
+	return WrappedWatcher{
+		stop: cancel,
+		pods: watcher.output,
+	}, nil
+}
+
+type podsWatcher struct {
+	pods            corev1.PodInterface
+	filter          PodFilter
+	known           map[types.UID]*v1.Pod
+	output          chan watch.Event
+	kube            client.Kube
+	reported        time.Time
+	resourceVersion string
+	options         metav1.ListOptions
+	resumes         uint
+}
+
+func (pw *podsWatcher) run(ctx context.Context, initial []watch.Event) {
+	defer close(pw.output)
+
+	for _, event := range initial {
+		if !pw.send(ctx, event) {
+			return
+		}
 	}
 
-	podsChan := make(chan watch.Event, runtime.NumCPU())
+	backoff := watchMinBackoff
+	var relist bool
 
-	go func() {
-		defer close(podsChan)
+	for {
+		if relist {
+			events, err := pw.list(ctx)
+			if err != nil {
+				if !pw.wait(ctx, &backoff, "relist pods: %s", err) {
+					return
+				}
 
-		for event := range watcher.ResultChan() {
-			pod, ok := event.Object.(*v1.Pod)
-			if !ok {
 				continue
 			}
 
-			if postListFilter(ctx, kube, *pod) {
-				podsChan <- event
+			for _, event := range events {
+				if !pw.send(ctx, event) {
+					return
+				}
 			}
+
+			relist = false
 		}
-	}()
 
-	return WrappedWatcher{
-		stop: watcher.Stop,
-		pods: podsChan,
-	}, nil
+		delivered, err := pw.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if delivered {
+			backoff = watchMinBackoff
+		}
+
+		switch {
+		case apierrors.IsResourceExpired(err) || apierrors.IsGone(err):
+			pw.kube.Warn("pods watch expired at resource version %s, relisting", pw.resourceVersion)
+			relist = true
+
+		case err != nil:
+			if !pw.wait(ctx, &backoff, "watch pods: %s", err) {
+				return
+			}
+
+		default:
+			if resumes, ok := pw.resumed(time.Now()); ok {
+				pw.kube.Info("pods watch closed by server (%d times since last report), resuming from resource version %s", resumes, pw.resourceVersion)
+			}
+
+			// a watch closed without any event is retried with backoff, for not hammering a server that closes them immediately
+			if !delivered && !pw.sleep(ctx, &backoff) {
+				return
+			}
+		}
+	}
+}
+
+// resumed counts a resume of the watch, returning the number of resumes to report when the last report is old enough
+func (pw *podsWatcher) resumed(now time.Time) (uint, bool) {
+	pw.resumes++
+
+	if now.Sub(pw.reported) < resumeReportInterval {
+		return 0, false
+	}
+
+	resumes := pw.resumes
+	pw.reported, pw.resumes = now, 0
+
+	return resumes, true
+}
+
+// list fetches the current pods and diffs them with the known ones, for generating the events missed while not watching
+func (pw *podsWatcher) list(ctx context.Context) ([]watch.Event, error) {
+	options := pw.options
+	options.Watch = false
+	options.ResourceVersion = ""
+
+	pods, err := pw.pods.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []watch.Event
+	seen := make(map[types.UID]bool, len(pods.Items))
+
+	for _, pod := range pods.Items {
+		previous, ok := pw.known[pod.UID]
+
+		switch {
+		case !ok && !pw.accept(ctx, pod):
+			continue
+
+		case !ok:
+			events = append(events, watch.Event{Type: watch.Added, Object: &pod})
+
+		case previous.ResourceVersion != pod.ResourceVersion:
+			events = append(events, watch.Event{Type: watch.Modified, Object: &pod})
+		}
+
+		seen[pod.UID] = true
+		pw.known[pod.UID] = &pod
+	}
+
+	for uid, pod := range pw.known {
+		if !seen[uid] {
+			events = append(events, watch.Event{Type: watch.Deleted, Object: pod})
+			delete(pw.known, uid)
+		}
+	}
+
+	pw.resourceVersion = pods.ResourceVersion
+
+	return events, nil
+}
+
+// watch forwards the events until the watch ends, the returned boolean indicates if the watch has delivered any event
+func (pw *podsWatcher) watch(ctx context.Context) (bool, error) {
+	options := pw.options
+	options.Watch = true
+	options.ResourceVersion = pw.resourceVersion
+	options.AllowWatchBookmarks = true
+
+	watcher, err := pw.pods.Watch(ctx, options)
+	if err != nil {
+		return false, err
+	}
+
+	defer watcher.Stop()
+
+	var delivered bool
+
+	for {
+		select {
+		case <-ctx.Done():
+			return delivered, ctx.Err()
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return delivered, nil
+			}
+
+			switch event.Type {
+			case watch.Error:
+				return delivered, apierrors.FromObject(event.Object)
+
+			case watch.Bookmark:
+				delivered = true
+
+				if object, err := meta.Accessor(event.Object); err == nil {
+					pw.resourceVersion = object.GetResourceVersion()
+				}
+
+			default:
+				pod, ok := event.Object.(*v1.Pod)
+				if !ok {
+					continue
+				}
+
+				pw.resourceVersion = pod.ResourceVersion
+
+				delivered = true
+
+				if _, known := pw.known[pod.UID]; !known && !pw.accept(ctx, *pod) {
+					continue
+				}
+
+				if event.Type == watch.Deleted {
+					delete(pw.known, pod.UID)
+				} else {
+					pw.known[pod.UID] = pod
+				}
+
+				if !pw.send(ctx, event) {
+					return delivered, ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+func (pw *podsWatcher) accept(ctx context.Context, pod v1.Pod) bool {
+	return pw.filter == nil || pw.filter(ctx, pw.kube, pod)
+}
+
+func (pw *podsWatcher) send(ctx context.Context, event watch.Event) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case pw.output <- event:
+		return true
+	}
+}
+
+func (pw *podsWatcher) wait(ctx context.Context, backoff *time.Duration, format string, err error) bool {
+	pw.kube.Warn(format+", retrying in %s", err, *backoff)
+
+	return pw.sleep(ctx, backoff)
+}
+
+// sleep waits for the backoff, doubled for the next time, and returns false if the context is done meanwhile
+func (pw *podsWatcher) sleep(ctx context.Context, backoff *time.Duration) bool {
+	timer := time.NewTimer(*backoff)
+	defer timer.Stop()
+
+	*backoff = min(*backoff*2, watchMaxBackoff)
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
 
 func watchPodsDry(ctx context.Context, kube client.Kube, namespace string, options metav1.ListOptions, postListFilter PodFilter) (watch.Interface, error) {
@@ -0,0 +1,148 @@
+package resource
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/kmux/pkg/client/clienttest"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func pod(name, resourceVersion string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name), ResourceVersion: resourceVersion},
+	}
+}
+
+func TestWatchPods(t *testing.T) {
+	t.Parallel()
+
+	kube := clienttest.New("prod", "default", pod("api-1", "1"))
+	fakeClient := clienttest.Fake(kube)
+
+	podsResource := v1.SchemeGroupVersion.WithResource("pods")
+
+	var calls int
+	fakeClient.PrependWatchReactor("pods", func(_ k8stesting.Action) (bool, watch.Interface, error) {
+		calls++
+
+		watcher := watch.NewFakeWithChanSize(1, false)
+
+		switch calls {
+		case 1:
+			watcher.Add(pod("api-2", "2"))
+			watcher.Stop()
+
+		case 2:
+			tracker := fakeClient.Tracker()
+
+			if err := tracker.Delete(podsResource, "default", "api-1"); err != nil {
+				t.Errorf("delete api-1: %s", err)
+			}
+
+			for _, item := range []runtime.Object{pod("api-2", "2"), pod("api-3", "3")} {
+				if err := tracker.Add(item); err != nil {
+					t.Errorf("add pod: %s", err)
+				}
+			}
+
+			status := apierrors.NewResourceExpired("too old resource version").ErrStatus
+			watcher.Error(&status)
+		}
+
+		return true, watcher, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher, err := WatchPods(ctx, kube, "", "", "", false)
+	if err != nil {
+		t.Fatalf("WatchPods() = %s", err)
+	}
+
+	defer watcher.Stop()
+
+	var got []string
+	for event := range watcher.ResultChan() {
+		got = append(got, string(event.Type)+" "+event.Object.(*v1.Pod).Name)
+
+		if len(got) == 4 {
+			break
+		}
+	}
+
+	slices.Sort(got[2:])
+
+	want := []string{"ADDED api-1", "ADDED api-2", "ADDED api-3", "DELETED api-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WatchPods() = %v, want %v", got, want)
+	}
+}
+
+func TestWatchPodsClosedWithoutEvent(t *testing.T) {
+	t.Parallel()
+
+	kube := clienttest.New("prod", "default", pod("api-1", "1"))
+
+	var calls atomic.Int32
+	clienttest.Fake(kube).PrependWatchReactor("pods", func(_ k8stesting.Action) (bool, watch.Interface, error) {
+		calls.Add(1)
+
+		watcher := watch.NewFake()
+		watcher.Stop()
+
+		return true, watcher, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher, err := WatchPods(ctx, kube, "", "", "", false)
+	if err != nil {
+		t.Fatalf("WatchPods() = %s", err)
+	}
+
+	<-watcher.ResultChan()
+	time.Sleep(watchMinBackoff / 2)
+
+	watcher.Stop()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("WatchPods() watched %d times, want 1", got)
+	}
+}
+
+func TestPodsWatcherResumed(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var watcher podsWatcher
+
+	type report struct {
+		resumes uint
+		ok      bool
+	}
+
+	var got []report
+	for _, at := range []time.Time{start, start.Add(time.Second), start.Add(2 * time.Second), start.Add(time.Minute), start.Add(3 * time.Minute)} {
+		resumes, ok := watcher.resumed(at)
+		got = append(got, report{resumes, ok})
+	}
+
+	// every resume is reported, the ones closely following a report being counted in the next one
+	if want := []report{{1, true}, {0, false}, {0, false}, {3, true}, {1, true}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed() = %+v, want %+v", got, want)
+	}
+}